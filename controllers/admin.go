@@ -0,0 +1,33 @@
+package controllers
+
+import (
+    "crypto/subtle"
+    "github.com/beego/beego/v2/server/web"
+    "net/http"
+)
+
+const adminTokenHeader = "X-Admin-Token"
+
+// requireAdmin guards the /api/admin endpoints: the caller must send the
+// configured admin_token in the X-Admin-Token header, and the admin API is
+// disabled while admin_token is unset. On failure it serves the error
+// itself and returns false.
+func (c *CatController) requireAdmin() bool {
+    token := web.AppConfig.DefaultString("admin_token", "")
+    given := c.Ctx.Input.Header(adminTokenHeader)
+    if token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+        return true
+    }
+
+    message := "admin token required"
+    if token == "" {
+        message = "admin API is disabled"
+    }
+    c.Ctx.Output.SetStatus(http.StatusForbidden)
+    c.Data["json"] = map[string]string{
+        "status":  "error",
+        "message": message,
+    }
+    c.ServeJSON()
+    return false
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "encoding/json"
+    "fmt"
+    "time"
+)
+
+type favoriteBatchRequest struct {
+    Atomic     bool                `json:"atomic"`
+    Operations []models.FavoriteOp `json:"operations"`
+}
+
+type voteBatchRequest struct {
+    Atomic bool                 `json:"atomic"`
+    Votes  []models.VoteRequest `json:"votes"`
+}
+
+var (
+    favoriteBatchChan = make(chan struct {
+        UserID  string
+        Batch   favoriteBatchRequest
+        ReqChan *RequestChannel
+    })
+    voteBatchChan = make(chan struct {
+        UserID  string
+        Batch   voteBatchRequest
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go favoriteBatchWorker()
+    go voteBatchWorker()
+}
+
+// BatchFavorites adds, removes and restores several favorites at once, for
+// multi-select in the grid.
+func (c *CatController) BatchFavorites() {
+    var batch favoriteBatchRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &batch); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    favoriteBatchChan <- struct {
+        UserID  string
+        Batch   favoriteBatchRequest
+        ReqChan *RequestChannel
+    }{c.currentUserID(), batch, reqChan}
+    c.handleBatchResponse(reqChan)
+}
+
+// BatchVotes records several votes at once, e.g. ones cast while offline.
+func (c *CatController) BatchVotes() {
+    var batch voteBatchRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &batch); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    voteBatchChan <- struct {
+        UserID  string
+        Batch   voteBatchRequest
+        ReqChan *RequestChannel
+    }{c.currentUserID(), batch, reqChan}
+    c.handleBatchResponse(reqChan)
+}
+
+// handleBatchResponse reports a cancelled atomic batch as an error while
+// still returning the per-item results, so clients can see what failed.
+func (c *CatController) handleBatchResponse(reqChan *RequestChannel) {
+    select {
+    case data := <-reqChan.ResponseChan:
+        response := map[string]interface{}{
+            "status": "success",
+            "data":   data,
+        }
+        if err := batchCancelled(data); err != nil {
+            response["status"] = "error"
+            response["message"] = err.Error()
+        }
+        c.Data["json"] = response
+    case err := <-reqChan.ErrorChan:
+        c.Data["json"] = map[string]interface{}{
+            "status":  "error",
+            "message": err.Error(),
+        }
+    }
+    c.ServeJSON()
+}
+
+func batchCancelled(data interface{}) error {
+    var result models.BatchResult
+    switch r := data.(type) {
+    case *models.BatchResult:
+        result = *r
+    case *models.VoteBatchResult:
+        result = r.BatchResult
+    }
+    if result.Cancelled {
+        return fmt.Errorf("batch cancelled: %d of %d operations failed", result.Failed, len(result.Results))
+    }
+    return nil
+}
+
+func favoriteBatchWorker() {
+    for req := range favoriteBatchChan {
+        ops := req.Batch.Operations
+        result, err := models.BatchFavorites(req.UserID, ops, req.Batch.Atomic)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+            continue
+        }
+
+        now := time.Now()
+        for _, item := range result.Results {
+            if item.Status != models.BatchOK {
+                continue
+            }
+            switch ops[item.Index].Op {
+            case models.FavoriteOpAdd, models.FavoriteOpRestore:
+                events.Publish(events.FavoriteAdded{UserID: req.UserID, ImageID: item.ID, ImageURL: item.URL, AddedAt: now})
+            case models.FavoriteOpRemove:
+                events.Publish(events.FavoriteRemoved{UserID: req.UserID, ImageID: item.ID, RemovedAt: now})
+            }
+        }
+        req.ReqChan.ResponseChan <- result
+    }
+}
+
+func voteBatchWorker() {
+    for req := range voteBatchChan {
+        result, err := models.BatchVotes(req.UserID, req.Batch.Votes, req.Batch.Atomic)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+            continue
+        }
+        for _, vote := range result.Votes {
+            publishVote(vote)
+        }
+        req.ReqChan.ResponseChan <- result
+    }
+}
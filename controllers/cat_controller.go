@@ -1,9 +1,11 @@
 package controllers
 
 import (
+    "CatVotingApp/events"
     "CatVotingApp/models"
     "encoding/json"
     "github.com/beego/beego/v2/server/web"
+    "time"
 )
 
 type CatController struct {
@@ -22,14 +24,21 @@ var (
     breedsChan   = make(chan *RequestChannel)
     breedDetailsChan = make(chan struct {
         ID string
+        Name string
         ReqChan *RequestChannel
     })
     voteChan     = make(chan struct {
+        UserID string
         Vote models.VoteRequest
         ReqChan *RequestChannel
     })
+    personalCatsChan = make(chan struct {
+        UserID string
+        ReqChan *RequestChannel
+    })
     favoritesChan = make(chan *RequestChannel)
     removeFavChan = make(chan struct {
+        UserID string
         ID string
         ReqChan *RequestChannel
     })
@@ -38,6 +47,7 @@ var (
 func init() {
     // Start the worker goroutines
     go catsWorker()
+    go personalCatsWorker()
     go breedsWorker()
     go breedDetailsWorker()
     go voteWorker()
@@ -54,7 +64,23 @@ func (c *CatController) GetCats() {
         ResponseChan: make(chan interface{}),
         ErrorChan:    make(chan error),
     }
-    catsChan <- reqChan
+
+    switch mode := c.GetString("mode"); mode {
+    case "", "random":
+        catsChan <- reqChan
+    case "personal":
+        personalCatsChan <- struct {
+            UserID string
+            ReqChan *RequestChannel
+        }{c.currentUserID(), reqChan}
+    default:
+        c.Data["json"] = map[string]interface{}{
+            "status":  "error",
+            "message": "unknown mode: " + mode,
+        }
+        c.ServeJSON()
+        return
+    }
     c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
 }
 
@@ -75,10 +101,11 @@ func (c *CatController) GetBreeds() {
 
 func (c *CatController) GetBreedDetails() {
     breedID := c.GetString("id")
-    if breedID == "" {
+    breedName := c.GetString("name")
+    if breedID == "" && breedName == "" {
         c.Data["json"] = map[string]interface{}{
             "status":  "error",
-            "message": "breed id is required",
+            "message": "breed id or name is required",
         }
         c.ServeJSON()
         return
@@ -91,8 +118,9 @@ func (c *CatController) GetBreedDetails() {
     
     breedDetailsChan <- struct {
         ID string
+        Name string
         ReqChan *RequestChannel
-    }{breedID, reqChan}
+    }{breedID, breedName, reqChan}
     
     select {
     case breed := <-reqChan.ResponseChan:
@@ -111,7 +139,7 @@ func (c *CatController) GetBreedDetails() {
 
 func (c *CatController) VoteCat() {
     var voteReq models.VoteRequest
-    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &voteReq); err != nil {
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &voteReq); err != nil || voteReq.ImageID == "" {
         c.Data["json"] = map[string]string{
             "status":  "error",
             "message": "Invalid request format",
@@ -126,9 +154,10 @@ func (c *CatController) VoteCat() {
     }
     
     voteChan <- struct {
+        UserID string
         Vote models.VoteRequest
         ReqChan *RequestChannel
-    }{voteReq, reqChan}
+    }{c.currentUserID(), voteReq, reqChan}
     
     select {
     case <-reqChan.ResponseChan:
@@ -151,7 +180,24 @@ func (c *CatController) GetFavorites() {
         ErrorChan:   make(chan error),
     }
     
-    favoritesChan <- reqChan
+    limit, _ := c.GetInt("limit", 0)
+    query := models.FavoriteQuery{
+        Collection: c.GetString("collection"),
+        Tag:        c.GetString("tag"),
+        Q:          c.GetString("q"),
+        Breed:      c.GetString("breed"),
+        Sort:       c.GetString("sort"),
+        Cursor:     c.GetString("cursor"),
+        Limit:      limit,
+    }
+    if query == (models.FavoriteQuery{}) {
+        favoritesChan <- reqChan
+    } else {
+        favoriteQueryChan <- struct {
+            Query   models.FavoriteQuery
+            ReqChan *RequestChannel
+        }{query, reqChan}
+    }
     c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
 
 }
@@ -173,9 +219,10 @@ func (c *CatController) RemoveFavorite() {
     }
     
     removeFavChan <- struct {
+        UserID string
         ID string
         ReqChan *RequestChannel
-    }{id, reqChan}
+    }{c.currentUserID(), id, reqChan}
     
     select {
     case <-reqChan.ResponseChan:
@@ -221,9 +268,20 @@ func catsWorker() {
     }
 }
 
+func personalCatsWorker() {
+    for req := range personalCatsChan {
+        cats, err := models.FetchPersonalCats(req.UserID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- cats
+        }
+    }
+}
+
 func breedsWorker() {
     for reqChan := range breedsChan {
-        breeds, err := models.FetchBreeds()
+        breeds, err := models.RefreshBreedCatalog()
         if err != nil {
             reqChan.ErrorChan <- err
         } else {
@@ -234,7 +292,16 @@ func breedsWorker() {
 
 func breedDetailsWorker() {
     for req := range breedDetailsChan {
-        breed, err := models.FetchBreedDetails(req.ID)
+        breedID := req.ID
+        var err error
+        if breedID == "" {
+            breedID, err = models.ResolveBreedID(req.Name)
+        }
+
+        var breed *models.Breed
+        if err == nil {
+            breed, err = models.FetchBreedDetails(breedID)
+        }
         if err != nil {
             req.ReqChan.ErrorChan <- err
         } else {
@@ -245,9 +312,9 @@ func breedDetailsWorker() {
 
 func voteWorker() {
     for req := range voteChan {
-        var err error
-        if req.Vote.Vote == "love" {
-            err = models.SaveFavorite(req.Vote.ImageID, req.Vote.ImageURL)
+        vote, err := models.CastVote(req.UserID, req.Vote)
+        if err == nil {
+            publishVote(vote)
         }
         
         if err != nil {
@@ -258,6 +325,28 @@ func voteWorker() {
     }
 }
 
+// publishVote announces a recorded vote and, for a love, the favorite it
+// added.
+func publishVote(vote models.Vote) {
+    events.Publish(events.VoteCast{
+        VoteID:   vote.ID,
+        UserID:   vote.UserID,
+        ImageID:  vote.ImageID,
+        ImageURL: vote.ImageURL,
+        BreedIDs: vote.BreedIDs,
+        Value:    vote.Value,
+        CastAt:   vote.CreatedAt,
+    })
+    if vote.Value == models.VoteLove {
+        events.Publish(events.FavoriteAdded{
+            UserID:   vote.UserID,
+            ImageID:  vote.ImageID,
+            ImageURL: vote.ImageURL,
+            AddedAt:  vote.CreatedAt,
+        })
+    }
+}
+
 func favoritesWorker() {
     for reqChan := range favoritesChan {
         favorites := models.GetFavorites()
@@ -267,9 +356,14 @@ func favoritesWorker() {
 
 func removeFavoriteWorker() {
     for req := range removeFavChan {
-        if err := models.RemoveFavorite(req.ID); err != nil {
+        if err := models.TrashFavorite(req.UserID, req.ID); err != nil {
             req.ReqChan.ErrorChan <- err
         } else {
+            events.Publish(events.FavoriteRemoved{
+                UserID:    req.UserID,
+                ImageID:   req.ID,
+                RemovedAt: time.Now(),
+            })
             req.ReqChan.ResponseChan <- true
         }
     }
@@ -3,6 +3,7 @@ package controllers
 import (
     "CatVotingApp/models"
     "encoding/json"
+    "io/ioutil"
     "github.com/beego/beego/v2/server/web"
     "github.com/beego/beego/v2/server/web/context"
     "github.com/stretchr/testify/assert"
@@ -15,6 +16,9 @@ import (
 func init() {
     web.AppConfig.Set("cat_api_url", "https://api.thecatapi.com/v1")
     web.AppConfig.Set("cat_api_key", "test_key")
+
+    dataDir, _ := ioutil.TempDir("", "catvoting-controllers")
+    web.AppConfig.Set("data_dir", dataDir)
 }
 
 func setupTestController(r *http.Request) (*CatController, *httptest.ResponseRecorder) {
@@ -264,7 +268,7 @@ func TestCatController_GetBreedDetails_MissingID(t *testing.T) {
     err := json.Unmarshal(w.Body.Bytes(), &response)
     assert.NoError(t, err)
     assert.Equal(t, "error", response["status"])
-    assert.Equal(t, "breed id is required", response["message"])
+    assert.Equal(t, "breed id or name is required", response["message"])
 }
 
 
@@ -309,4 +313,17 @@ func TestCatController_GetFavorites_EmptyResponse(t *testing.T) {
 
 func ErrMock(message string) error {
     return errors.New(message)
-}
\ No newline at end of file
+}
+
+func TestCatController_GetCats_UnknownMode(t *testing.T) {
+    r, _ := http.NewRequest("GET", "/api/cats?mode=shuffle", nil)
+    controller, w := setupTestController(r)
+
+    controller.GetCats()
+
+    var response map[string]interface{}
+    err := json.Unmarshal(w.Body.Bytes(), &response)
+    assert.NoError(t, err)
+    assert.Equal(t, "error", response["status"])
+    assert.Equal(t, "unknown mode: shuffle", response["message"])
+}
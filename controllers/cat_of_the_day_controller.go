@@ -0,0 +1,65 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+)
+
+var (
+    catOfTheDayChan        = make(chan *RequestChannel)
+    catOfTheDayHistoryChan = make(chan struct {
+        Limit   int
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go catOfTheDayWorker()
+    go catOfTheDayHistoryWorker()
+}
+
+func (c *CatController) GetCatOfTheDay() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    catOfTheDayChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetCatOfTheDayHistory() {
+    limit, _ := c.GetInt("limit", 30)
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    catOfTheDayHistoryChan <- struct {
+        Limit   int
+        ReqChan *RequestChannel
+    }{limit, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func catOfTheDayWorker() {
+    for reqChan := range catOfTheDayChan {
+        pick, err := models.GetCatOfTheDay()
+        if err != nil {
+            reqChan.ErrorChan <- err
+        } else {
+            reqChan.ResponseChan <- pick
+        }
+    }
+}
+
+func catOfTheDayHistoryWorker() {
+    for req := range catOfTheDayHistoryChan {
+        history, err := models.GetCatOfTheDayHistory(req.Limit)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- history
+        }
+    }
+}
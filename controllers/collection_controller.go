@@ -0,0 +1,232 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "encoding/json"
+    "time"
+)
+
+type collectionRequest struct {
+    Name string `json:"name"`
+}
+
+type collectionImageRequest struct {
+    ImageID  string `json:"image_id"`
+    ImageURL string `json:"image_url"`
+}
+
+var (
+    collectionsChan      = make(chan *RequestChannel)
+    createCollectionChan = make(chan struct {
+        Name    string
+        ReqChan *RequestChannel
+    })
+    renameCollectionChan = make(chan struct {
+        ID      string
+        Name    string
+        ReqChan *RequestChannel
+    })
+    deleteCollectionChan = make(chan struct {
+        ID      string
+        ReqChan *RequestChannel
+    })
+    addToCollectionChan = make(chan struct {
+        ID      string
+        UserID  string
+        Image   collectionImageRequest
+        ReqChan *RequestChannel
+    })
+    removeFromCollectionChan = make(chan struct {
+        ID      string
+        ImageID string
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go collectionsWorker()
+    go createCollectionWorker()
+    go renameCollectionWorker()
+    go deleteCollectionWorker()
+    go addToCollectionWorker()
+    go removeFromCollectionWorker()
+}
+
+func (c *CatController) GetCollections() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    collectionsChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) CreateCollection() {
+    var collectionReq collectionRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &collectionReq); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    createCollectionChan <- struct {
+        Name    string
+        ReqChan *RequestChannel
+    }{collectionReq.Name, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) RenameCollection() {
+    var collectionReq collectionRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &collectionReq); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    renameCollectionChan <- struct {
+        ID      string
+        Name    string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), collectionReq.Name, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) DeleteCollection() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    deleteCollectionChan <- struct {
+        ID      string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+// AddToCollection adds or moves an image into a collection. Images that
+// aren't favorites yet need an image_url and become favorites.
+func (c *CatController) AddToCollection() {
+    var imageReq collectionImageRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &imageReq); err != nil || imageReq.ImageID == "" {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    addToCollectionChan <- struct {
+        ID      string
+        UserID  string
+        Image   collectionImageRequest
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), c.currentUserID(), imageReq, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) RemoveFromCollection() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    removeFromCollectionChan <- struct {
+        ID      string
+        ImageID string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), c.Ctx.Input.Param(":image_id"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func collectionsWorker() {
+    for reqChan := range collectionsChan {
+        reqChan.ResponseChan <- models.GetCollections()
+    }
+}
+
+func createCollectionWorker() {
+    for req := range createCollectionChan {
+        collection, err := models.CreateCollection(req.Name)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- collection
+        }
+    }
+}
+
+func renameCollectionWorker() {
+    for req := range renameCollectionChan {
+        collection, err := models.RenameCollection(req.ID, req.Name)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- collection
+        }
+    }
+}
+
+func deleteCollectionWorker() {
+    for req := range deleteCollectionChan {
+        if err := models.DeleteCollection(req.ID); err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- true
+        }
+    }
+}
+
+func addToCollectionWorker() {
+    for req := range addToCollectionChan {
+        added, err := models.AddToCollection(req.ID, req.Image.ImageID, req.Image.ImageURL)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+            continue
+        }
+        if added {
+            events.Publish(events.FavoriteAdded{
+                UserID:   req.UserID,
+                ImageID:  req.Image.ImageID,
+                ImageURL: req.Image.ImageURL,
+                AddedAt:  time.Now(),
+            })
+        }
+        req.ReqChan.ResponseChan <- true
+    }
+}
+
+func removeFromCollectionWorker() {
+    for req := range removeFromCollectionChan {
+        if err := models.RemoveFromCollection(req.ID, req.ImageID); err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- true
+        }
+    }
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "fmt"
+    "strings"
+)
+
+const maxCompareBreeds = 10
+
+// compareError is returned when none of the breeds could be loaded. It
+// keeps the error of each one so callers can tell what went wrong.
+type compareError struct {
+    Errors map[string]string
+}
+
+func (e *compareError) Error() string {
+    return "none of the requested breeds could be loaded"
+}
+
+var compareChan = make(chan struct {
+    IDs     []string
+    ReqChan *RequestChannel
+})
+
+func init() {
+    go compareWorker()
+}
+
+func (c *CatController) CompareBreeds() {
+    ids, err := parseBreedIDs(c.GetString("ids"))
+    if err != nil {
+        c.Data["json"] = map[string]interface{}{
+            "status":  "error",
+            "message": err.Error(),
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    compareChan <- struct {
+        IDs     []string
+        ReqChan *RequestChannel
+    }{ids, reqChan}
+
+    select {
+    case data := <-reqChan.ResponseChan:
+        c.Data["json"] = map[string]interface{}{
+            "status": "success",
+            "data":   data,
+        }
+    case err := <-reqChan.ErrorChan:
+        response := map[string]interface{}{
+            "status":  "error",
+            "message": err.Error(),
+        }
+        if compareErr, ok := err.(*compareError); ok {
+            response["errors"] = compareErr.Errors
+        }
+        c.Data["json"] = response
+    }
+    c.ServeJSON()
+}
+
+// ComparePage renders the same comparison as CompareBreeds as an HTML table.
+func (c *CatController) ComparePage() {
+    c.TplName = "compare.tpl"
+
+    ids, err := parseBreedIDs(c.GetString("ids"))
+    if err != nil {
+        c.Data["Error"] = err.Error()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    compareChan <- struct {
+        IDs     []string
+        ReqChan *RequestChannel
+    }{ids, reqChan}
+
+    select {
+    case data := <-reqChan.ResponseChan:
+        c.Data["Comparison"] = data
+    case err := <-reqChan.ErrorChan:
+        c.Data["Error"] = err.Error()
+        if compareErr, ok := err.(*compareError); ok {
+            c.Data["Errors"] = compareErr.Errors
+        }
+    }
+}
+
+// parseBreedIDs splits a comma-separated ids parameter, dropping blanks and
+// duplicates.
+func parseBreedIDs(raw string) ([]string, error) {
+    ids := []string{}
+    seen := make(map[string]bool)
+    for _, id := range strings.Split(raw, ",") {
+        id = strings.ToLower(strings.TrimSpace(id))
+        if id == "" || seen[id] {
+            continue
+        }
+        seen[id] = true
+        ids = append(ids, id)
+    }
+
+    if len(ids) == 0 {
+        return nil, fmt.Errorf("breed ids are required")
+    }
+    if len(ids) > maxCompareBreeds {
+        return nil, fmt.Errorf("at most %d breeds can be compared", maxCompareBreeds)
+    }
+    return ids, nil
+}
+
+func compareWorker() {
+    for req := range compareChan {
+        comparison := models.CompareBreeds(req.IDs)
+        if len(comparison.Breeds) == 0 {
+            req.ReqChan.ErrorChan <- &compareError{Errors: comparison.Errors}
+        } else {
+            req.ReqChan.ResponseChan <- comparison
+        }
+    }
+}
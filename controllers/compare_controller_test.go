@@ -0,0 +1,38 @@
+package controllers
+
+import (
+    "encoding/json"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "strings"
+    "testing"
+)
+
+func TestParseBreedIDs(t *testing.T) {
+    ids, err := parseBreedIDs(" abys, BENG,,abys ,bomb")
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"abys", "beng", "bomb"}, ids)
+
+    _, err = parseBreedIDs(" , ")
+    assert.Error(t, err)
+
+    tooMany := make([]string, maxCompareBreeds+1)
+    for i := range tooMany {
+        tooMany[i] = strings.Repeat("x", i+1)
+    }
+    _, err = parseBreedIDs(strings.Join(tooMany, ","))
+    assert.Error(t, err)
+}
+
+func TestCatController_CompareBreeds_MissingIDs(t *testing.T) {
+    r, _ := http.NewRequest("GET", "/api/breeds/compare", nil)
+    controller, w := setupTestController(r)
+
+    controller.CompareBreeds()
+
+    var response map[string]interface{}
+    err := json.Unmarshal(w.Body.Bytes(), &response)
+    assert.NoError(t, err)
+    assert.Equal(t, "error", response["status"])
+    assert.Equal(t, "breed ids are required", response["message"])
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "encoding/json"
+)
+
+var (
+    duelChan = make(chan struct {
+        UserID  string
+        ReqChan *RequestChannel
+    })
+    duelResultChan = make(chan struct {
+        UserID  string
+        Result  models.DuelResult
+        ReqChan *RequestChannel
+    })
+    duelRankingsChan = make(chan struct {
+        Limit   int
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go duelWorker()
+    go duelResultWorker()
+    go duelRankingsWorker()
+}
+
+func (c *CatController) GetDuel() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    duelChan <- struct {
+        UserID  string
+        ReqChan *RequestChannel
+    }{c.currentUserID(), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) RecordDuel() {
+    var result models.DuelResult
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &result); err != nil || result.DuelID == "" || result.WinnerID == "" {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    duelResultChan <- struct {
+        UserID  string
+        Result  models.DuelResult
+        ReqChan *RequestChannel
+    }{c.currentUserID(), result, reqChan}
+
+    select {
+    case <-reqChan.ResponseChan:
+        c.Data["json"] = map[string]string{
+            "status":  "success",
+            "message": "Duel recorded",
+        }
+    case err := <-reqChan.ErrorChan:
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": err.Error(),
+        }
+    }
+    c.ServeJSON()
+}
+
+func (c *CatController) GetDuelRankings() {
+    limit, _ := c.GetInt("limit", 10)
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    duelRankingsChan <- struct {
+        Limit   int
+        ReqChan *RequestChannel
+    }{limit, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func duelWorker() {
+    for req := range duelChan {
+        duel, err := models.NewDuel(req.UserID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- duel
+        }
+    }
+}
+
+func duelResultWorker() {
+    for req := range duelResultChan {
+        if err := models.RecordDuel(req.UserID, req.Result); err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- true
+        }
+    }
+}
+
+func duelRankingsWorker() {
+    for req := range duelRankingsChan {
+        req.ReqChan.ResponseChan <- models.GetDuelRankings(req.Limit)
+    }
+}
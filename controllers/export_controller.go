@@ -0,0 +1,246 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "bytes"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+)
+
+const (
+    exportJSON = "json"
+    exportCSV  = "csv"
+    exportZIP  = "zip"
+)
+
+var (
+    exportFavoritesChan = make(chan *RequestChannel)
+    zipExportChan       = make(chan *RequestChannel)
+    exportJobChan       = make(chan struct {
+        ID      string
+        ReqChan *RequestChannel
+    })
+    importFavoritesChan = make(chan struct {
+        UserID  string
+        Rows    []models.FavoriteRow
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go exportFavoritesWorker()
+    go zipExportWorker()
+    go exportJobWorker()
+    go importFavoritesWorker()
+}
+
+// ExportFavorites downloads the favorites as JSON or CSV. A ZIP of the
+// images takes a while, so it is built in the background: the response is
+// the export job, with links to poll it and to download the finished file.
+func (c *CatController) ExportFavorites() {
+    format := strings.ToLower(c.GetString("format", exportJSON))
+    if format == exportZIP {
+        reqChan := &RequestChannel{
+            ResponseChan: make(chan interface{}),
+            ErrorChan:    make(chan error),
+        }
+        zipExportChan <- reqChan
+        c.Ctx.Output.SetStatus(http.StatusAccepted)
+        c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+        return
+    }
+    if format != exportJSON && format != exportCSV {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": fmt.Sprintf("unknown export format: %q", format),
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+    exportFavoritesChan <- reqChan
+    var rows []models.FavoriteRow
+    select {
+    case response := <-reqChan.ResponseChan:
+        rows = response.([]models.FavoriteRow)
+    case err := <-reqChan.ErrorChan:
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": err.Error(),
+        }
+        c.ServeJSON()
+        return
+    }
+
+    filename := "favorites-" + time.Now().Format("2006-01-02") + "." + format
+    c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+    if format == exportJSON {
+        c.Data["json"] = rows
+        c.ServeJSON()
+        return
+    }
+
+    var buf bytes.Buffer
+    if err := models.WriteFavoritesCSV(&buf, rows); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": err.Error(),
+        }
+        c.ServeJSON()
+        return
+    }
+    c.EnableRender = false
+    c.Ctx.Output.Header("Content-Type", "text/csv; charset=utf-8")
+    c.Ctx.Output.Body(buf.Bytes())
+}
+
+// GetExportJob reports the progress of a ZIP export.
+func (c *CatController) GetExportJob() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    exportJobChan <- struct {
+        ID      string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":job"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) DownloadExport() {
+    path, err := models.ExportJobFile(c.Ctx.Input.Param(":job"))
+    if err != nil {
+        c.Ctx.Output.SetStatus(http.StatusNotFound)
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": err.Error(),
+        }
+        c.ServeJSON()
+        return
+    }
+    c.EnableRender = false
+    c.Ctx.Output.Download(path, "favorites.zip")
+}
+
+// ImportFavorites adds favorites from a JSON or CSV export, reporting the
+// outcome of every row. The format is taken from the format query parameter
+// or else the Content-Type.
+func (c *CatController) ImportFavorites() {
+    format := strings.ToLower(c.GetString("format"))
+    if format == "" {
+        format = exportJSON
+        if strings.Contains(c.Ctx.Input.Header("Content-Type"), "csv") {
+            format = exportCSV
+        }
+    }
+
+    var (
+        rows []models.FavoriteRow
+        err  error
+    )
+    switch format {
+    case exportJSON:
+        rows, err = models.ParseFavoritesJSON(c.Ctx.Input.RequestBody)
+    case exportCSV:
+        rows, err = models.ParseFavoritesCSV(c.Ctx.Input.RequestBody)
+    default:
+        err = fmt.Errorf("unknown import format: %q", format)
+    }
+    if err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": err.Error(),
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    importFavoritesChan <- struct {
+        UserID  string
+        Rows    []models.FavoriteRow
+        ReqChan *RequestChannel
+    }{c.currentUserID(), rows, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func exportFavoritesWorker() {
+    for reqChan := range exportFavoritesChan {
+        rows, err := models.ExportFavorites()
+        if err != nil {
+            reqChan.ErrorChan <- err
+        } else {
+            reqChan.ResponseChan <- rows
+        }
+    }
+}
+
+func zipExportWorker() {
+    for reqChan := range zipExportChan {
+        job, err := models.StartFavoritesZipExport()
+        if err != nil {
+            reqChan.ErrorChan <- err
+        } else {
+            reqChan.ResponseChan <- exportJobResponse(job)
+        }
+    }
+}
+
+func exportJobWorker() {
+    for req := range exportJobChan {
+        job, err := models.GetExportJob(req.ID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- exportJobResponse(job)
+        }
+    }
+}
+
+// importFavoritesWorker announces every imported favorite so the stream,
+// webhooks and TheCatAPI sync see them like any other.
+func importFavoritesWorker() {
+    for req := range importFavoritesChan {
+        report, err := models.ImportFavorites(req.Rows)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+            continue
+        }
+        for i, result := range report.Results {
+            if result.Status != models.ImportImported {
+                continue
+            }
+            events.Publish(events.FavoriteAdded{
+                UserID:   req.UserID,
+                ImageID:  result.ID,
+                ImageURL: req.Rows[i].URL,
+                AddedAt:  time.Now(),
+            })
+        }
+        req.ReqChan.ResponseChan <- report
+    }
+}
+
+// exportJobResponse adds the links a client follows to the job.
+func exportJobResponse(job *models.ExportJob) map[string]interface{} {
+    response := map[string]interface{}{
+        "job":        job,
+        "status_url": "/api/favorites/export/" + job.ID,
+    }
+    if job.Status == models.ExportDone {
+        response["download_url"] = "/api/favorites/export/" + job.ID + "/download"
+    }
+    return response
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "encoding/json"
+)
+
+var (
+    favoriteQueryChan = make(chan struct {
+        Query   models.FavoriteQuery
+        ReqChan *RequestChannel
+    })
+    updateFavoriteChan = make(chan struct {
+        ID      string
+        Update  models.FavoriteUpdate
+        ReqChan *RequestChannel
+    })
+    reorderFavoritesChan = make(chan struct {
+        IDs     []string
+        ReqChan *RequestChannel
+    })
+    favoriteTagsChan   = make(chan *RequestChannel)
+    favoriteBreedsChan = make(chan *RequestChannel)
+)
+
+func init() {
+    go favoriteQueryWorker()
+    go updateFavoriteWorker()
+    go reorderFavoritesWorker()
+    go favoriteTagsWorker()
+    go favoriteBreedsWorker()
+}
+
+// UpdateFavorite edits a favorite's notes and tags; fields missing from the
+// body are left alone.
+func (c *CatController) UpdateFavorite() {
+    var update models.FavoriteUpdate
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &update); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    updateFavoriteChan <- struct {
+        ID      string
+        Update  models.FavoriteUpdate
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), update, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+// ReorderFavorites saves the manual order of the favorites grid. The body
+// lists image IDs; favorites left out keep their order after them.
+func (c *CatController) ReorderFavorites() {
+    var req struct {
+        IDs []string `json:"ids"`
+    }
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    reorderFavoritesChan <- struct {
+        IDs     []string
+        ReqChan *RequestChannel
+    }{req.IDs, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetFavoriteTags() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    favoriteTagsChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetFavoriteBreeds() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    favoriteBreedsChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+// favoriteQueryWorker answers with a plain list unless the client asked for
+// a page, so existing callers keep getting an array.
+func favoriteQueryWorker() {
+    for req := range favoriteQueryChan {
+        var (
+            result interface{}
+            err    error
+        )
+        if req.Query.Cursor != "" || req.Query.Limit > 0 {
+            result, err = models.PageFavorites(req.Query)
+        } else {
+            result, err = models.QueryFavorites(req.Query)
+        }
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- result
+        }
+    }
+}
+
+func updateFavoriteWorker() {
+    for req := range updateFavoriteChan {
+        favorite, err := models.UpdateFavorite(req.ID, req.Update)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- favorite
+        }
+    }
+}
+
+func reorderFavoritesWorker() {
+    for req := range reorderFavoritesChan {
+        if err := models.ReorderFavorites(req.IDs); err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- models.GetFavorites()
+        }
+    }
+}
+
+func favoriteTagsWorker() {
+    for reqChan := range favoriteTagsChan {
+        reqChan.ResponseChan <- models.GetFavoriteTags()
+    }
+}
+
+func favoriteBreedsWorker() {
+    for reqChan := range favoriteBreedsChan {
+        reqChan.ResponseChan <- models.GetFavoriteBreedDistribution()
+    }
+}
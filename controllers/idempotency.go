@@ -0,0 +1,86 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "github.com/beego/beego/v2/core/logs"
+    "net/http"
+)
+
+const (
+    idempotencyKeyHeader      = "Idempotency-Key"
+    idempotencyReplayedHeader = "Idempotent-Replayed"
+
+    // idempotencyKeyData marks a request that claimed its key, so Finish
+    // knows to store the response.
+    idempotencyKeyData = "idempotency_key"
+)
+
+// Prepare makes write requests that carry an Idempotency-Key safe to retry:
+// a retry of a request already handled gets the first response replayed
+// instead of being run again. Keys are scoped to the caller.
+func (c *CatController) Prepare() {
+    key := c.Ctx.Input.Header(idempotencyKeyHeader)
+    if key == "" || !isWriteMethod(c.Ctx.Input.Method()) {
+        return
+    }
+
+    fingerprint := models.IdempotencyFingerprint(c.Ctx.Input.Method(), c.Ctx.Input.URL(), c.Ctx.Input.RequestBody)
+    stored, err := models.ClaimIdempotencyKey(c.idempotencyScope(), key, fingerprint)
+    if err != nil {
+        status := http.StatusBadRequest
+        if err == models.ErrIdempotencyKeyReused {
+            status = http.StatusUnprocessableEntity
+        }
+        c.Ctx.Output.SetStatus(status)
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": err.Error(),
+        }
+        c.ServeJSON()
+        return
+    }
+    if stored != nil {
+        c.Ctx.Output.Header(idempotencyReplayedHeader, "true")
+        c.Ctx.Output.Header("Content-Type", "application/json; charset=utf-8")
+        c.Ctx.Output.SetStatus(stored.Status)
+        c.Ctx.Output.Body(stored.Body)
+        return
+    }
+    c.Ctx.Input.SetData(idempotencyKeyData, key)
+}
+
+// Finish stores the response of a request that claimed an idempotency key.
+// Server errors aren't stored, so a retry gets another go.
+func (c *CatController) Finish() {
+    key, _ := c.Ctx.Input.GetData(idempotencyKeyData).(string)
+    if key == "" {
+        return
+    }
+
+    status := c.Ctx.ResponseWriter.Status
+    if status == 0 {
+        status = http.StatusOK
+    }
+    body, ok := c.Data["json"]
+    if !ok || status >= http.StatusInternalServerError {
+        models.ReleaseIdempotencyKey(c.idempotencyScope(), key)
+        return
+    }
+    if err := models.CompleteIdempotencyKey(c.idempotencyScope(), key, status, body); err != nil {
+        logs.Error("Error storing idempotent response: %v", err)
+    }
+}
+
+// idempotencyScope is the caller. A client without a cookie gets its ID
+// here, so its keys never share a space with other new clients'.
+func (c *CatController) idempotencyScope() string {
+    return c.currentUserID()
+}
+
+func isWriteMethod(method string) bool {
+    switch method {
+    case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+        return true
+    }
+    return false
+}
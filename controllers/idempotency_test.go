@@ -0,0 +1,56 @@
+package controllers
+
+import (
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestIdempotencyKeyReplaysResponse(t *testing.T) {
+    body := []byte(`{"image_id":"idem1","vote":"like"}`)
+    cookie := ""
+    newRequest := func(body []byte) (*CatController, *httptest.ResponseRecorder) {
+        r, _ := http.NewRequest("POST", "/api/vote", nil)
+        r.Header.Set("Idempotency-Key", "double-click")
+        if cookie != "" {
+            r.Header.Set("Cookie", cookie)
+        }
+        c, w := setupTestController(r)
+        setRequestBody(c, body)
+        return c, w
+    }
+
+    // A new client gets its ID with the first response and its own keys.
+    first, w := newRequest(body)
+    first.Prepare()
+    assert.False(t, first.Ctx.ResponseWriter.Started, "the first request runs")
+    first.Data["json"] = map[string]string{"status": "success", "message": "Vote recorded"}
+    first.ServeJSON()
+    first.Finish()
+    assert.Equal(t, first.idempotencyScope(), first.currentUserID(), "one ID per request")
+
+    stranger, _ := newRequest(body)
+    stranger.Prepare()
+    assert.False(t, stranger.Ctx.ResponseWriter.Started, "other new clients don't share the key")
+    cookie = strings.SplitN(w.Header().Get("Set-Cookie"), ";", 2)[0]
+
+    retry, w := newRequest(body)
+    retry.Prepare()
+    assert.True(t, retry.Ctx.ResponseWriter.Started, "the retry is answered before the handler runs")
+    assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+    assert.JSONEq(t, `{"status":"success","message":"Vote recorded"}`, w.Body.String())
+
+    reused, w := newRequest([]byte(`{"image_id":"idem1","vote":"love"}`))
+    reused.Prepare()
+    assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotencyKeyIgnoredForReads(t *testing.T) {
+    r, _ := http.NewRequest("GET", "/api/favorites", nil)
+    r.Header.Set("Idempotency-Key", "read")
+    c, _ := setupTestController(r)
+    c.Prepare()
+    assert.Nil(t, c.Ctx.Input.GetData(idempotencyKeyData))
+}
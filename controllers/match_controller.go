@@ -0,0 +1,49 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "encoding/json"
+)
+
+var matchChan = make(chan struct {
+    Request models.MatchRequest
+    ReqChan *RequestChannel
+})
+
+func init() {
+    go matchWorker()
+}
+
+func (c *CatController) MatchBreeds() {
+    var matchReq models.MatchRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &matchReq); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    matchChan <- struct {
+        Request models.MatchRequest
+        ReqChan *RequestChannel
+    }{matchReq, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func matchWorker() {
+    for req := range matchChan {
+        matches, err := models.MatchBreeds(req.Request)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- matches
+        }
+    }
+}
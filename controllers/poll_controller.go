@@ -0,0 +1,185 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "encoding/json"
+)
+
+var (
+    createPollChan = make(chan struct {
+        UserID  string
+        Poll    models.PollRequest
+        ReqChan *RequestChannel
+    })
+    pollsChan = make(chan *RequestChannel)
+    pollChan  = make(chan struct {
+        ID      string
+        ReqChan *RequestChannel
+    })
+    pollVoteChan = make(chan struct {
+        PollID  string
+        UserID  string
+        Vote    models.VoteRequest
+        ReqChan *RequestChannel
+    })
+    pollResultsChan = make(chan struct {
+        ID      string
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go createPollWorker()
+    go pollsWorker()
+    go pollWorker()
+    go pollVoteWorker()
+    go pollResultsWorker()
+}
+
+func (c *CatController) CreatePoll() {
+    var pollReq models.PollRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &pollReq); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    createPollChan <- struct {
+        UserID  string
+        Poll    models.PollRequest
+        ReqChan *RequestChannel
+    }{c.currentUserID(), pollReq, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetPolls() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    pollsChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetPoll() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    pollChan <- struct {
+        ID      string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+// VotePoll is the poll-scoped variant of VoteCat.
+func (c *CatController) VotePoll() {
+    var voteReq models.VoteRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &voteReq); err != nil || voteReq.ImageID == "" {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    pollVoteChan <- struct {
+        PollID  string
+        UserID  string
+        Vote    models.VoteRequest
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), c.currentUserID(), voteReq, reqChan}
+
+    select {
+    case <-reqChan.ResponseChan:
+        c.Data["json"] = map[string]string{
+            "status":  "success",
+            "message": "Vote recorded",
+        }
+    case err := <-reqChan.ErrorChan:
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": err.Error(),
+        }
+    }
+    c.ServeJSON()
+}
+
+func (c *CatController) GetPollResults() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    pollResultsChan <- struct {
+        ID      string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func createPollWorker() {
+    for req := range createPollChan {
+        poll, err := models.CreatePoll(req.UserID, req.Poll)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- poll
+        }
+    }
+}
+
+func pollsWorker() {
+    for reqChan := range pollsChan {
+        reqChan.ResponseChan <- models.GetPolls()
+    }
+}
+
+func pollWorker() {
+    for req := range pollChan {
+        poll, err := models.GetPoll(req.ID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- poll
+        }
+    }
+}
+
+func pollVoteWorker() {
+    for req := range pollVoteChan {
+        if err := models.CastPollVote(req.PollID, req.UserID, req.Vote); err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- true
+        }
+    }
+}
+
+func pollResultsWorker() {
+    for req := range pollResultsChan {
+        results, err := models.GetPollResults(req.ID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- results
+        }
+    }
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "strings"
+)
+
+var (
+    searchChan = make(chan struct {
+        Query   string
+        Limit   int
+        ReqChan *RequestChannel
+    })
+    suggestChan = make(chan struct {
+        Prefix  string
+        Limit   int
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go searchWorker()
+    go suggestWorker()
+}
+
+func (c *CatController) SearchBreeds() {
+    query := strings.TrimSpace(c.GetString("q"))
+    if query == "" {
+        c.Data["json"] = map[string]interface{}{
+            "status":  "error",
+            "message": "search query is required",
+        }
+        c.ServeJSON()
+        return
+    }
+    limit, _ := c.GetInt("limit", 10)
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    searchChan <- struct {
+        Query   string
+        Limit   int
+        ReqChan *RequestChannel
+    }{query, limit, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) SuggestBreeds() {
+    prefix := strings.TrimSpace(c.GetString("prefix"))
+    if prefix == "" {
+        c.Data["json"] = map[string]interface{}{
+            "status":  "error",
+            "message": "prefix is required",
+        }
+        c.ServeJSON()
+        return
+    }
+    limit, _ := c.GetInt("limit", 8)
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    suggestChan <- struct {
+        Prefix  string
+        Limit   int
+        ReqChan *RequestChannel
+    }{prefix, limit, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func searchWorker() {
+    for req := range searchChan {
+        hits, err := models.SearchBreeds(req.Query, req.Limit)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- hits
+        }
+    }
+}
+
+func suggestWorker() {
+    for req := range suggestChan {
+        suggestions, err := models.SuggestBreeds(req.Prefix, req.Limit)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- suggestions
+        }
+    }
+}
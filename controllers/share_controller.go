@@ -0,0 +1,174 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "encoding/json"
+    "net/http"
+    "time"
+)
+
+var (
+    createShareChan = make(chan struct {
+        UserID  string
+        Request models.ShareRequest
+        ReqChan *RequestChannel
+    })
+    sharesChan = make(chan struct {
+        UserID  string
+        ReqChan *RequestChannel
+    })
+    revokeShareChan = make(chan struct {
+        UserID  string
+        Token   string
+        ReqChan *RequestChannel
+    })
+    viewShareChan = make(chan struct {
+        Token   string
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go createShareWorker()
+    go sharesWorker()
+    go revokeShareWorker()
+    go viewShareWorker()
+}
+
+// CreateShare publishes the current user's favorites, or one collection,
+// at /share/:token.
+func (c *CatController) CreateShare() {
+    var req models.ShareRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    createShareChan <- struct {
+        UserID  string
+        Request models.ShareRequest
+        ReqChan *RequestChannel
+    }{c.currentUserID(), req, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetShares() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    sharesChan <- struct {
+        UserID  string
+        ReqChan *RequestChannel
+    }{c.currentUserID(), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) RevokeShare() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    revokeShareChan <- struct {
+        UserID  string
+        Token   string
+        ReqChan *RequestChannel
+    }{c.currentUserID(), c.Ctx.Input.Param(":token"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+// SharePage renders a share for anyone holding the link, with OpenGraph
+// tags so chat apps and social sites show a preview.
+func (c *CatController) SharePage() {
+    c.TplName = "share.tpl"
+    c.Data["PageURL"] = c.Ctx.Input.Scheme() + "://" + c.Ctx.Request.Host + c.Ctx.Input.URI()
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    viewShareChan <- struct {
+        Token   string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":token"), reqChan}
+
+    select {
+    case data := <-reqChan.ResponseChan:
+        shared := data.(*models.SharedFavorites)
+        c.Data["Shared"] = shared
+        if len(shared.Images) > 0 {
+            c.Data["PreviewImage"] = shared.Images[0].URL
+        }
+    case err := <-reqChan.ErrorChan:
+        c.Ctx.Output.SetStatus(http.StatusNotFound)
+        c.Data["Error"] = err.Error()
+    }
+}
+
+func createShareWorker() {
+    for req := range createShareChan {
+        share, err := models.CreateShare(req.UserID, req.Request)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- shareResponse(share)
+        }
+    }
+}
+
+func sharesWorker() {
+    for req := range sharesChan {
+        shares, err := models.GetShares(req.UserID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+            continue
+        }
+        result := make([]map[string]interface{}, 0, len(shares))
+        for i := range shares {
+            result = append(result, shareResponse(&shares[i]))
+        }
+        req.ReqChan.ResponseChan <- result
+    }
+}
+
+func revokeShareWorker() {
+    for req := range revokeShareChan {
+        share, err := models.RevokeShare(req.UserID, req.Token)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- shareResponse(share)
+        }
+    }
+}
+
+func viewShareWorker() {
+    for req := range viewShareChan {
+        shared, err := models.ViewShare(req.Token, time.Now())
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- shared
+        }
+    }
+}
+
+// shareResponse adds the public link to a share.
+func shareResponse(share *models.Share) map[string]interface{} {
+    return map[string]interface{}{
+        "share": share,
+        "path":  "/share/" + share.Token,
+    }
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+)
+
+var similarChan = make(chan struct {
+    ID      string
+    N       int
+    ReqChan *RequestChannel
+})
+
+func init() {
+    go similarWorker()
+}
+
+func (c *CatController) GetSimilarBreeds() {
+    breedID := c.Ctx.Input.Param(":id")
+    if breedID == "" {
+        c.Data["json"] = map[string]interface{}{
+            "status":  "error",
+            "message": "breed id is required",
+        }
+        c.ServeJSON()
+        return
+    }
+    n, _ := c.GetInt("n", 5)
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    similarChan <- struct {
+        ID      string
+        N       int
+        ReqChan *RequestChannel
+    }{breedID, n, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func similarWorker() {
+    for req := range similarChan {
+        similar, err := models.SimilarBreeds(req.ID, req.N)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- similar
+        }
+    }
+}
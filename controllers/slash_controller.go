@@ -0,0 +1,104 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/server/web"
+    "net/http"
+    "time"
+)
+
+var slashChan = make(chan struct {
+    Command models.SlashCommand
+    // Delayed is closed once the caller has been acknowledged and the
+    // answer must go to the command's response URL instead.
+    Delayed chan struct{}
+    ReqChan *RequestChannel
+})
+
+func init() {
+    go slashWorker()
+}
+
+// SlashCommand serves Slack-style slash commands. Chat platforms give up
+// after three seconds, so when the answer takes longer than
+// slash_ack_timeout the command is acknowledged right away and the answer
+// is posted to its response_url once ready.
+func (c *CatController) SlashCommand() {
+    secret := web.AppConfig.DefaultString("slash_signing_secret", "")
+    if secret == "" {
+        c.slashError(http.StatusForbidden, "slash commands are disabled")
+        return
+    }
+
+    body := c.Ctx.Input.RequestBody
+    err := models.VerifySlashSignature(secret,
+        c.Ctx.Input.Header("X-Slack-Request-Timestamp"),
+        c.Ctx.Input.Header("X-Slack-Signature"),
+        body, time.Now())
+    if err != nil {
+        c.slashError(http.StatusUnauthorized, err.Error())
+        return
+    }
+
+    cmd, err := models.ParseSlashCommand(body)
+    if err != nil {
+        c.slashError(http.StatusBadRequest, "Invalid request format")
+        return
+    }
+
+    timeout, err := time.ParseDuration(web.AppConfig.DefaultString("slash_ack_timeout", "2500ms"))
+    if err != nil || timeout <= 0 {
+        timeout = 2500 * time.Millisecond
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+    delayed := make(chan struct{})
+
+    slashChan <- struct {
+        Command models.SlashCommand
+        Delayed chan struct{}
+        ReqChan *RequestChannel
+    }{cmd, delayed, reqChan}
+
+    timer := time.NewTimer(timeout)
+    defer timer.Stop()
+
+    select {
+    case msg := <-reqChan.ResponseChan:
+        c.Data["json"] = msg
+    case <-timer.C:
+        close(delayed)
+        c.Data["json"] = models.SlashAcknowledgement()
+    }
+    c.ServeJSON()
+}
+
+func (c *CatController) slashError(status int, message string) {
+    c.Ctx.Output.SetStatus(status)
+    c.Data["json"] = map[string]string{
+        "status":  "error",
+        "message": message,
+    }
+    c.ServeJSON()
+}
+
+// slashWorker answers each command in its own goroutine: upstream calls can
+// be slow and one command must not hold up the next.
+func slashWorker() {
+    for req := range slashChan {
+        go func() {
+            msg := models.RunSlashCommand(req.Command)
+            select {
+            case req.ReqChan.ResponseChan <- msg:
+            case <-req.Delayed:
+                if err := models.PostSlashResponse(req.Command.ResponseURL, msg); err != nil {
+                    logs.Error("Error posting slash command response: %v", err)
+                }
+            }
+        }()
+    }
+}
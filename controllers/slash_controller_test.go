@@ -0,0 +1,93 @@
+package controllers
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strconv"
+    "testing"
+    "time"
+)
+
+func slashRequest(secret string, form url.Values) (*CatController, *httptest.ResponseRecorder) {
+    body := []byte(form.Encode())
+    timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte("v0:" + timestamp + ":"))
+    mac.Write(body)
+
+    r, _ := http.NewRequest("POST", "/api/integrations/slash", nil)
+    r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+    r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+    controller, w := setupTestController(r)
+    setRequestBody(controller, body)
+    return controller, w
+}
+
+func TestCatController_SlashCommand_Verification(t *testing.T) {
+    controller, w := slashRequest("secret", url.Values{"command": {"/cat"}})
+    controller.SlashCommand()
+    assert.Equal(t, http.StatusForbidden, w.Code, "disabled without a signing secret")
+
+    web.AppConfig.Set("slash_signing_secret", "secret")
+    defer web.AppConfig.Set("slash_signing_secret", "")
+
+    controller, w = slashRequest("wrong", url.Values{"command": {"/cat"}})
+    controller.SlashCommand()
+    assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+    controller, w = slashRequest("secret", url.Values{"command": {"/cat"}, "text": {"help"}})
+    controller.SlashCommand()
+
+    var response map[string]interface{}
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+    assert.Equal(t, http.StatusOK, w.Code)
+    assert.Equal(t, "ephemeral", response["response_type"])
+}
+
+func TestCatController_SlashCommand_DelayedResponse(t *testing.T) {
+    web.AppConfig.Set("slash_signing_secret", "secret")
+    web.AppConfig.Set("slash_ack_timeout", "20ms")
+    defer web.AppConfig.Set("slash_signing_secret", "")
+    defer web.AppConfig.Set("slash_ack_timeout", "2500ms")
+
+    catAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        time.Sleep(200 * time.Millisecond)
+        w.Write([]byte(`[{"id":"slow","url":"http://example.com/slow.jpg"}]`))
+    }))
+    defer catAPI.Close()
+    originalURL, _ := web.AppConfig.String("cat_api_url")
+    web.AppConfig.Set("cat_api_url", catAPI.URL)
+    defer web.AppConfig.Set("cat_api_url", originalURL)
+
+    delivered := make(chan []byte, 1)
+    responseURL := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        delivered <- body
+    }))
+    defer responseURL.Close()
+
+    controller, w := slashRequest("secret", url.Values{"command": {"/cat"}, "response_url": {responseURL.URL}})
+    controller.SlashCommand()
+
+    var ack map[string]interface{}
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ack))
+    assert.Equal(t, "ephemeral", ack["response_type"])
+
+    select {
+    case body := <-delivered:
+        var msg map[string]interface{}
+        assert.NoError(t, json.Unmarshal(body, &msg))
+        assert.Equal(t, "in_channel", msg["response_type"])
+        assert.Equal(t, "http://example.com/slow.jpg", msg["text"])
+    case <-time.After(2 * time.Second):
+        t.Fatal("the delayed response was never posted")
+    }
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/server/web"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// Stream event types pushed to browsers over /api/stream.
+const (
+    EventVoteRecorded       = "vote_recorded"
+    EventVoteRetracted      = "vote_retracted"
+    EventFavoriteAdded      = "favorite_added"
+    EventFavoriteRemoved    = "favorite_removed"
+    EventLeaderboardChanged = "leaderboard_changed"
+    EventCatOfTheDayChanged = "cat_of_the_day_changed"
+)
+
+// leaderboardSize is how many trending images count as the leaderboard
+// when deciding whether a vote changed it.
+const leaderboardSize = 5
+
+type StreamEvent struct {
+    Type string      `json:"type"`
+    Data interface{} `json:"data"`
+    At   time.Time   `json:"at"`
+}
+
+// streamClient is one connected browser. Events are buffered per
+// connection; a client that lets its buffer fill up is evicted rather than
+// slowing everyone else down.
+type streamClient struct {
+    events chan StreamEvent
+}
+
+type streamHub struct {
+    mu      sync.Mutex
+    clients map[*streamClient]bool
+}
+
+var hub = &streamHub{clients: make(map[*streamClient]bool)}
+
+func (h *streamHub) subscribe(buffer int) *streamClient {
+    client := &streamClient{events: make(chan StreamEvent, buffer)}
+
+    h.mu.Lock()
+    h.clients[client] = true
+    h.mu.Unlock()
+    return client
+}
+
+func (h *streamHub) unsubscribe(client *streamClient) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    if h.clients[client] {
+        delete(h.clients, client)
+        close(client.events)
+    }
+}
+
+func (h *streamHub) broadcast(event StreamEvent) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    for client := range h.clients {
+        select {
+        case client.events <- event:
+        default:
+            logs.Warn("Evicting slow stream client")
+            delete(h.clients, client)
+            close(client.events)
+        }
+    }
+}
+
+// BroadcastEvent pushes an event to every connected stream client.
+func BroadcastEvent(eventType string, data interface{}) {
+    hub.broadcast(StreamEvent{Type: eventType, Data: data, At: time.Now()})
+}
+
+func init() {
+    streamCast, streamRetracted := streamVotes()
+    events.SubscribeAsync(events.VoteCastEvent, "stream", streamCast)
+    events.SubscribeAsync(events.VoteRetractedEvent, "stream", streamRetracted)
+    events.SubscribeAsync(events.FavoriteAddedEvent, "stream", func(e events.Event) error {
+        added := e.(events.FavoriteAdded)
+        BroadcastEvent(EventFavoriteAdded, models.FavoriteImage{ID: added.ImageID, URL: added.ImageURL})
+        return nil
+    })
+    events.SubscribeAsync(events.FavoriteRemovedEvent, "stream", func(e events.Event) error {
+        BroadcastEvent(EventFavoriteRemoved, map[string]string{"id": e.(events.FavoriteRemoved).ImageID})
+        return nil
+    })
+    events.SubscribeAsync(events.CatOfTheDayChosenEvent, "stream", func(e events.Event) error {
+        BroadcastEvent(EventCatOfTheDayChanged, e)
+        return nil
+    })
+}
+
+// streamVotes announces each vote and each undone vote and, when that
+// reshuffled the top of the trending list, the new leaderboard. The voter's
+// ID is deliberately left out: it identifies their session.
+func streamVotes() (cast events.Handler, retracted events.Handler) {
+    var mu sync.Mutex
+    var leaders []string
+    leaderboardChanged := func() {
+        mu.Lock()
+        defer mu.Unlock()
+
+        current := trendingLeaders()
+        if fmt.Sprint(current) != fmt.Sprint(leaders) {
+            leaders = current
+            BroadcastEvent(EventLeaderboardChanged, models.GetTrending(leaderboardSize))
+        }
+    }
+
+    cast = func(e events.Event) error {
+        vote := e.(events.VoteCast)
+        BroadcastEvent(EventVoteRecorded, map[string]interface{}{
+            "image_id":  vote.ImageID,
+            "image_url": vote.ImageURL,
+            "vote":      vote.Value,
+            "breed_ids": vote.BreedIDs,
+        })
+        leaderboardChanged()
+        return nil
+    }
+    retracted = func(e events.Event) error {
+        vote := e.(events.VoteRetracted)
+        BroadcastEvent(EventVoteRetracted, map[string]interface{}{
+            "image_id":  vote.ImageID,
+            "vote":      vote.Value,
+            "breed_ids": vote.BreedIDs,
+        })
+        leaderboardChanged()
+        return nil
+    }
+    return cast, retracted
+}
+
+func trendingLeaders() []string {
+    ids := []string{}
+    for _, item := range models.GetTrending(leaderboardSize).Images {
+        ids = append(ids, item.ID)
+    }
+    return ids
+}
+
+// Stream serves Server-Sent Events until the browser disconnects or is
+// evicted for falling behind. A comment line is sent every stream_heartbeat
+// so proxies keep the connection open.
+func (c *CatController) Stream() {
+    c.EnableRender = false
+
+    w := c.Ctx.ResponseWriter
+    flusher, ok := w.ResponseWriter.(http.Flusher)
+    if !ok {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "streaming is not supported",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    heartbeat, err := time.ParseDuration(web.AppConfig.DefaultString("stream_heartbeat", "15s"))
+    if err != nil || heartbeat <= 0 {
+        heartbeat = 15 * time.Second
+    }
+    client := hub.subscribe(web.AppConfig.DefaultInt("stream_buffer", 32))
+    defer hub.unsubscribe(client)
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    w.WriteHeader(http.StatusOK)
+    fmt.Fprint(w, ": connected\n\n")
+    flusher.Flush()
+
+    ticker := time.NewTicker(heartbeat)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case event, ok := <-client.events:
+            if !ok {
+                return
+            }
+            data, err := json.Marshal(event)
+            if err != nil {
+                continue
+            }
+            fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+            flusher.Flush()
+        case <-ticker.C:
+            fmt.Fprint(w, ": heartbeat\n\n")
+            flusher.Flush()
+        case <-c.Ctx.Request.Context().Done():
+            return
+        }
+    }
+}
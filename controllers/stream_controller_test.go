@@ -0,0 +1,59 @@
+package controllers
+
+import (
+    "context"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestStreamHubEvictsSlowClients(t *testing.T) {
+    client := hub.subscribe(1)
+    defer hub.unsubscribe(client)
+
+    BroadcastEvent(EventFavoriteRemoved, map[string]string{"id": "a"})
+    BroadcastEvent(EventFavoriteRemoved, map[string]string{"id": "b"})
+
+    event, ok := <-client.events
+    assert.True(t, ok)
+    assert.Equal(t, EventFavoriteRemoved, event.Type)
+
+    _, ok = <-client.events
+    assert.False(t, ok, "a client whose buffer overflowed is evicted")
+}
+
+func TestCatController_Stream(t *testing.T) {
+    ctx, cancel := context.WithCancel(context.Background())
+    r, _ := http.NewRequest("GET", "/api/stream", nil)
+    r = r.WithContext(ctx)
+    controller, w := setupTestController(r)
+
+    done := make(chan struct{})
+    go func() {
+        controller.Stream()
+        close(done)
+    }()
+
+    // Wait for the connection to subscribe before broadcasting.
+    for i := 0; i < 100; i++ {
+        hub.mu.Lock()
+        subscribed := len(hub.clients) > 0
+        hub.mu.Unlock()
+        if subscribed {
+            break
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    BroadcastEvent(EventFavoriteRemoved, map[string]string{"id": "test123"})
+    time.Sleep(50 * time.Millisecond)
+    cancel()
+    <-done
+
+    body := w.Body.String()
+    assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+    assert.True(t, strings.HasPrefix(body, ": connected"))
+    assert.Contains(t, body, "event: favorite_removed\n")
+    assert.Contains(t, body, `"id":"test123"`)
+}
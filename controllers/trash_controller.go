@@ -0,0 +1,74 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "time"
+)
+
+var (
+    favoriteTrashChan   = make(chan *RequestChannel)
+    restoreFavoriteChan = make(chan struct {
+        UserID  string
+        ID      string
+        ReqChan *RequestChannel
+    })
+)
+
+func init() {
+    go favoriteTrashWorker()
+    go restoreFavoriteWorker()
+}
+
+// GetFavoriteTrash lists the removed favorites that can still be restored.
+func (c *CatController) GetFavoriteTrash() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    favoriteTrashChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) RestoreFavorite() {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    restoreFavoriteChan <- struct {
+        UserID  string
+        ID      string
+        ReqChan *RequestChannel
+    }{c.currentUserID(), c.Ctx.Input.Param(":id"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func favoriteTrashWorker() {
+    for reqChan := range favoriteTrashChan {
+        trash, err := models.GetFavoriteTrash()
+        if err != nil {
+            reqChan.ErrorChan <- err
+        } else {
+            reqChan.ResponseChan <- trash
+        }
+    }
+}
+
+func restoreFavoriteWorker() {
+    for req := range restoreFavoriteChan {
+        favorite, err := models.RestoreFavorite(req.ID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+            continue
+        }
+        events.Publish(events.FavoriteAdded{
+            UserID:   req.UserID,
+            ImageID:  favorite.ID,
+            ImageURL: favorite.URL,
+            AddedAt:  time.Now(),
+        })
+        req.ReqChan.ResponseChan <- favorite
+    }
+}
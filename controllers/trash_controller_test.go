@@ -0,0 +1,50 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "encoding/json"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+)
+
+func TestCatController_RemoveFavoriteMovesItToTheTrash(t *testing.T) {
+    assert.NoError(t, models.SaveFavorite("trash123", "http://example.com/trash.jpg"))
+
+    r, _ := http.NewRequest("DELETE", "/api/favorites/trash123", nil)
+    controller, w := setupTestController(r)
+    controller.Ctx.Input.SetParam(":id", "trash123")
+    controller.RemoveFavorite()
+
+    var response map[string]interface{}
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+    assert.Equal(t, "success", response["status"])
+    for _, fav := range models.GetFavorites() {
+        assert.NotEqual(t, "trash123", fav.ID)
+    }
+
+    r, _ = http.NewRequest("GET", "/api/favorites/trash", nil)
+    controller, w = setupTestController(r)
+    controller.GetFavoriteTrash()
+
+    var trash struct {
+        Data []models.TrashedFavorite `json:"data"`
+    }
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+    assert.Len(t, trash.Data, 1)
+    assert.Equal(t, "trash123", trash.Data[0].ID)
+
+    r, _ = http.NewRequest("POST", "/api/favorites/trash123/restore", nil)
+    controller, w = setupTestController(r)
+    controller.Ctx.Input.SetParam(":id", "trash123")
+    controller.RestoreFavorite()
+
+    response = nil
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+    assert.Equal(t, "success", response["status"])
+    restored := false
+    for _, fav := range models.GetFavorites() {
+        restored = restored || fav.ID == "trash123"
+    }
+    assert.True(t, restored, "the favorite is back")
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+)
+
+var trendingChan = make(chan struct {
+    Limit   int
+    ReqChan *RequestChannel
+})
+
+func init() {
+    go trendingWorker()
+
+    // Synchronous, so the vote shows up in /api/trending as soon as the
+    // vote request returns.
+    events.Subscribe(events.VoteCastEvent, "trending", func(e events.Event) error {
+        cast := e.(events.VoteCast)
+        models.RecordTrend(models.Vote{
+            ID:        cast.VoteID,
+            UserID:    cast.UserID,
+            ImageID:   cast.ImageID,
+            ImageURL:  cast.ImageURL,
+            BreedIDs:  cast.BreedIDs,
+            Value:     cast.Value,
+            CreatedAt: cast.CastAt,
+        })
+        return nil
+    })
+    events.Subscribe(events.VoteRetractedEvent, "trending", func(e events.Event) error {
+        retracted := e.(events.VoteRetracted)
+        models.RetractTrend(models.Vote{
+            ID:        retracted.VoteID,
+            UserID:    retracted.UserID,
+            ImageID:   retracted.ImageID,
+            ImageURL:  retracted.ImageURL,
+            BreedIDs:  retracted.BreedIDs,
+            Value:     retracted.Value,
+            CreatedAt: retracted.CastAt,
+        })
+        return nil
+    })
+}
+
+func (c *CatController) GetTrending() {
+    limit, _ := c.GetInt("limit", 10)
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    trendingChan <- struct {
+        Limit   int
+        ReqChan *RequestChannel
+    }{limit, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func trendingWorker() {
+    for req := range trendingChan {
+        req.ReqChan.ResponseChan <- models.GetTrending(req.Limit)
+    }
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "time"
+)
+
+var retractVoteChan = make(chan struct {
+    UserID  string
+    VoteID  string // empty for the user's most recent vote
+    ReqChan *RequestChannel
+})
+
+func init() {
+    go retractVoteWorker()
+}
+
+// RetractVote undoes one of the current user's votes.
+func (c *CatController) RetractVote() {
+    c.retractVote(c.Ctx.Input.Param(":id"))
+}
+
+// UndoVote undoes the current user's most recent vote.
+func (c *CatController) UndoVote() {
+    c.retractVote("")
+}
+
+func (c *CatController) retractVote(voteID string) {
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    retractVoteChan <- struct {
+        UserID  string
+        VoteID  string
+        ReqChan *RequestChannel
+    }{c.currentUserID(), voteID, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func retractVoteWorker() {
+    for req := range retractVoteChan {
+        var retracted *models.RetractedVote
+        var err error
+        if req.VoteID == "" {
+            retracted, err = models.UndoLastVote(req.UserID)
+        } else {
+            retracted, err = models.RetractVote(req.UserID, req.VoteID)
+        }
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+            continue
+        }
+
+        now := time.Now()
+        vote := retracted.Vote
+        events.Publish(events.VoteRetracted{
+            VoteID:      vote.ID,
+            UserID:      vote.UserID,
+            ImageID:     vote.ImageID,
+            ImageURL:    vote.ImageURL,
+            BreedIDs:    vote.BreedIDs,
+            Value:       vote.Value,
+            CastAt:      vote.CreatedAt,
+            RetractedAt: now,
+        })
+        if retracted.FavoriteRemoved {
+            events.Publish(events.FavoriteRemoved{UserID: req.UserID, ImageID: vote.ImageID, RemovedAt: now})
+        }
+        req.ReqChan.ResponseChan <- retracted
+    }
+}
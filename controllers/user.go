@@ -0,0 +1,78 @@
+package controllers
+
+import (
+    "CatVotingApp/models"
+    "crypto/rand"
+    "encoding/hex"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/server/web"
+    "sync"
+)
+
+const (
+    userCookie = "cat_user_id"
+
+    // userIDData keeps the caller's ID for the rest of the request, so a
+    // new client is only handed one.
+    userIDData = "user_id"
+)
+
+var (
+    // fallbackSecret signs user cookies when the stored secret can't be
+    // read; they then stop being valid at the next restart.
+    fallbackSecret     string
+    fallbackSecretOnce sync.Once
+)
+
+// currentUserID identifies the caller for the vote ledger, the trash and
+// shares. Every client gets a random ID in a long-lived cookie on its first
+// request. The cookie is signed and HttpOnly, so callers can't pick or
+// forge someone else's ID.
+func (c *CatController) currentUserID() string {
+    if id, ok := c.Ctx.Input.GetData(userIDData).(string); ok && id != "" {
+        return id
+    }
+    if id := c.cookieUserID(); id != "" {
+        c.Ctx.Input.SetData(userIDData, id)
+        return id
+    }
+
+    buf := make([]byte, 16)
+    if _, err := rand.Read(buf); err != nil {
+        return "anonymous"
+    }
+    id := hex.EncodeToString(buf)
+    c.Ctx.SetSecureCookie(userCookieSecret(), userCookie, id, 365*24*60*60, "/", "", false, true)
+    c.Ctx.Input.SetData(userIDData, id)
+    return id
+}
+
+// cookieUserID is the ID from a validly signed cookie, or "" when the
+// request has none.
+func (c *CatController) cookieUserID() string {
+    id, ok := c.Ctx.GetSecureCookie(userCookieSecret(), userCookie)
+    if !ok {
+        return ""
+    }
+    return id
+}
+
+// userCookieSecret is user_cookie_secret, or else a secret generated once
+// and kept in the data directory.
+func userCookieSecret() string {
+    if secret := web.AppConfig.DefaultString("user_cookie_secret", ""); secret != "" {
+        return secret
+    }
+    secret, err := models.Secret("user_cookie")
+    if err == nil {
+        return secret
+    }
+
+    logs.Error("Error loading the user cookie secret: %v", err)
+    fallbackSecretOnce.Do(func() {
+        buf := make([]byte, 32)
+        rand.Read(buf)
+        fallbackSecret = hex.EncodeToString(buf)
+    })
+    return fallbackSecret
+}
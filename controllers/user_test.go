@@ -0,0 +1,33 @@
+package controllers
+
+import (
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "strings"
+    "testing"
+)
+
+func TestCurrentUserIDCookie(t *testing.T) {
+    r, _ := http.NewRequest("GET", "/api/cats", nil)
+    r.Header.Set("X-User-ID", "someone-else")
+    c, w := setupTestController(r)
+
+    id := c.currentUserID()
+    assert.NotEqual(t, "someone-else", id, "the header can't pick an ID")
+    cookie := w.Header().Get("Set-Cookie")
+    assert.Contains(t, cookie, userCookie+"=")
+    assert.Contains(t, cookie, "HttpOnly")
+
+    // The signed cookie comes back as the same user.
+    value := strings.TrimPrefix(strings.SplitN(cookie, ";", 2)[0], userCookie+"=")
+    r, _ = http.NewRequest("GET", "/api/cats", nil)
+    r.AddCookie(&http.Cookie{Name: userCookie, Value: value})
+    c, _ = setupTestController(r)
+    assert.Equal(t, id, c.currentUserID())
+
+    // A cookie naming another ID without a valid signature is ignored.
+    r, _ = http.NewRequest("GET", "/api/cats", nil)
+    r.AddCookie(&http.Cookie{Name: userCookie, Value: "alice"})
+    c, _ = setupTestController(r)
+    assert.NotEqual(t, "alice", c.currentUserID())
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "encoding/json"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/server/web"
+    "time"
+)
+
+var (
+    createWebhookChan = make(chan struct {
+        Webhook models.WebhookRequest
+        ReqChan *RequestChannel
+    })
+    webhooksChan      = make(chan *RequestChannel)
+    deleteWebhookChan = make(chan struct {
+        ID      string
+        ReqChan *RequestChannel
+    })
+    deadLettersChan = make(chan *RequestChannel)
+    redeliverChan   = make(chan struct {
+        ID      string
+        ReqChan *RequestChannel
+    })
+
+    // webhookKick wakes the dispatcher as soon as something is queued.
+    webhookKick = make(chan struct{}, 1)
+)
+
+func init() {
+    go createWebhookWorker()
+    go webhooksWorker()
+    go deleteWebhookWorker()
+    go deadLettersWorker()
+    go redeliverWorker()
+
+    for _, name := range models.WebhookEvents {
+        events.SubscribeAsync(name, "webhooks", func(e events.Event) error {
+            queued, err := models.EnqueueWebhookEvent(e.EventName(), e)
+            if queued > 0 {
+                kickWebhooks()
+            }
+            return err
+        })
+    }
+}
+
+func (c *CatController) CreateWebhook() {
+    if !c.requireAdmin() {
+        return
+    }
+
+    var webhookReq models.WebhookRequest
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &webhookReq); err != nil {
+        c.Data["json"] = map[string]string{
+            "status":  "error",
+            "message": "Invalid request format",
+        }
+        c.ServeJSON()
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    createWebhookChan <- struct {
+        Webhook models.WebhookRequest
+        ReqChan *RequestChannel
+    }{webhookReq, reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetWebhooks() {
+    if !c.requireAdmin() {
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    webhooksChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) DeleteWebhook() {
+    if !c.requireAdmin() {
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    deleteWebhookChan <- struct {
+        ID      string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) GetWebhookDeadLetters() {
+    if !c.requireAdmin() {
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    deadLettersChan <- reqChan
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func (c *CatController) RedeliverWebhook() {
+    if !c.requireAdmin() {
+        return
+    }
+
+    reqChan := &RequestChannel{
+        ResponseChan: make(chan interface{}),
+        ErrorChan:    make(chan error),
+    }
+
+    redeliverChan <- struct {
+        ID      string
+        ReqChan *RequestChannel
+    }{c.Ctx.Input.Param(":id"), reqChan}
+    c.handleWorkerResponse(reqChan.ResponseChan, reqChan.ErrorChan)
+}
+
+func createWebhookWorker() {
+    for req := range createWebhookChan {
+        webhook, err := models.CreateWebhook(req.Webhook)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- webhook
+        }
+    }
+}
+
+func webhooksWorker() {
+    for reqChan := range webhooksChan {
+        webhooks, err := models.GetWebhooks()
+        if err != nil {
+            reqChan.ErrorChan <- err
+        } else {
+            reqChan.ResponseChan <- webhooks
+        }
+    }
+}
+
+func deleteWebhookWorker() {
+    for req := range deleteWebhookChan {
+        if err := models.DeleteWebhook(req.ID); err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            req.ReqChan.ResponseChan <- true
+        }
+    }
+}
+
+func deadLettersWorker() {
+    for reqChan := range deadLettersChan {
+        deadLetters, err := models.GetWebhookDeadLetters()
+        if err != nil {
+            reqChan.ErrorChan <- err
+        } else {
+            reqChan.ResponseChan <- deadLetters
+        }
+    }
+}
+
+func redeliverWorker() {
+    for req := range redeliverChan {
+        delivery, err := models.RedeliverWebhook(req.ID)
+        if err != nil {
+            req.ReqChan.ErrorChan <- err
+        } else {
+            kickWebhooks()
+            req.ReqChan.ResponseChan <- delivery
+        }
+    }
+}
+
+// StartWebhookDispatcher starts sending queued webhook deliveries. It is
+// called from main once the configuration is loaded.
+func StartWebhookDispatcher() {
+    interval, err := time.ParseDuration(web.AppConfig.DefaultString("webhook_poll_interval", "10s"))
+    if err != nil || interval <= 0 {
+        interval = 10 * time.Second
+    }
+    go webhookDispatcher(interval)
+}
+
+// webhookDispatcher sends queued deliveries whenever something is queued,
+// and every interval to pick up retries that have come due.
+func webhookDispatcher(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ticker.C:
+        case <-webhookKick:
+        }
+        if _, err := models.DeliverDueWebhooks(time.Now()); err != nil {
+            logs.Error("Error delivering webhooks: %v", err)
+        }
+    }
+}
+
+func kickWebhooks() {
+    select {
+    case webhookKick <- struct{}{}:
+    default:
+    }
+}
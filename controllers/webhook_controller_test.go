@@ -0,0 +1,57 @@
+package controllers
+
+import (
+    "encoding/json"
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+)
+
+func TestCatController_CreateWebhook_RequiresAdmin(t *testing.T) {
+    cases := []struct {
+        token   string
+        header  string
+        message string
+    }{
+        {"", "anything", "admin API is disabled"},
+        {"secret", "", "admin token required"},
+        {"secret", "wrong", "admin token required"},
+    }
+
+    for _, tc := range cases {
+        web.AppConfig.Set("admin_token", tc.token)
+        r, _ := http.NewRequest("POST", "/api/admin/webhooks", nil)
+        r.Header.Set(adminTokenHeader, tc.header)
+        controller, w := setupTestController(r)
+
+        controller.CreateWebhook()
+
+        var response map[string]interface{}
+        assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+        assert.Equal(t, http.StatusForbidden, w.Code)
+        assert.Equal(t, tc.message, response["message"])
+    }
+    web.AppConfig.Set("admin_token", "")
+}
+
+func TestCatController_CreateWebhook(t *testing.T) {
+    web.AppConfig.Set("admin_token", "secret")
+    defer web.AppConfig.Set("admin_token", "")
+
+    r, _ := http.NewRequest("POST", "/api/admin/webhooks", nil)
+    r.Header.Set(adminTokenHeader, "secret")
+    controller, w := setupTestController(r)
+    setRequestBody(controller, []byte(`{"url": "http://example.com/hook", "events": ["vote_cast"]}`))
+
+    controller.CreateWebhook()
+
+    var response struct {
+        Status string
+        Data   map[string]interface{}
+    }
+    assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+    assert.Equal(t, "success", response.Status)
+    assert.Equal(t, "http://example.com/hook", response.Data["url"])
+    assert.NotEmpty(t, response.Data["secret"])
+}
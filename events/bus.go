@@ -0,0 +1,132 @@
+package events
+
+import (
+    "errors"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "sync"
+)
+
+// AllEvents subscribes a handler to every event.
+const AllEvents = "*"
+
+// asyncQueueSize is how many events an asynchronous subscriber can fall
+// behind before further events for it are dropped.
+const asyncQueueSize = 256
+
+type Handler func(Event) error
+
+type subscription struct {
+    name    string
+    handler Handler
+    queue   chan Event // nil for synchronous subscribers
+}
+
+// Bus delivers published events to subscribers. Synchronous subscribers run
+// in the publisher's goroutine, in subscription order, and all of them
+// finish before any asynchronous subscriber sees the event. Asynchronous
+// subscribers each get their own goroutine and queue. A subscriber that
+// fails or panics is logged and does not affect the publisher or other
+// subscribers.
+type Bus struct {
+    mu      sync.RWMutex
+    subs    map[string][]*subscription
+    pending sync.WaitGroup
+}
+
+func New() *Bus {
+    return &Bus{subs: make(map[string][]*subscription)}
+}
+
+// Subscribe registers a handler that runs before Publish returns.
+func (b *Bus) Subscribe(eventName string, name string, handler Handler) {
+    b.add(eventName, &subscription{name: name, handler: handler})
+}
+
+// SubscribeAsync registers a handler that runs in the background, in the
+// order events were published.
+func (b *Bus) SubscribeAsync(eventName string, name string, handler Handler) {
+    sub := &subscription{name: name, handler: handler, queue: make(chan Event, asyncQueueSize)}
+    b.add(eventName, sub)
+
+    go func() {
+        for event := range sub.queue {
+            sub.deliver(event)
+            b.pending.Done()
+        }
+    }()
+}
+
+// Publish delivers the event to its subscribers. It returns the errors of
+// the synchronous subscribers, joined, for callers that want to log them.
+func (b *Bus) Publish(event Event) error {
+    b.mu.RLock()
+    subs := append(append([]*subscription{}, b.subs[event.EventName()]...), b.subs[AllEvents]...)
+    b.mu.RUnlock()
+
+    var errs []error
+    for _, sub := range subs {
+        if sub.queue != nil {
+            continue
+        }
+        if err := sub.deliver(event); err != nil {
+            errs = append(errs, err)
+        }
+    }
+
+    for _, sub := range subs {
+        if sub.queue == nil {
+            continue
+        }
+        b.pending.Add(1)
+        select {
+        case sub.queue <- event:
+        default:
+            b.pending.Done()
+            logs.Error("Dropping %s event for slow subscriber %s", event.EventName(), sub.name)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// Wait blocks until every asynchronous delivery queued so far has finished.
+func (b *Bus) Wait() {
+    b.pending.Wait()
+}
+
+func (b *Bus) add(eventName string, sub *subscription) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.subs[eventName] = append(b.subs[eventName], sub)
+}
+
+func (s *subscription) deliver(event Event) (err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("subscriber %s panicked on %s: %v", s.name, event.EventName(), r)
+        }
+        if err != nil {
+            logs.Error("Event subscriber error: %v", err)
+        }
+    }()
+
+    if err := s.handler(event); err != nil {
+        return fmt.Errorf("subscriber %s failed on %s: %w", s.name, event.EventName(), err)
+    }
+    return nil
+}
+
+// Default is the application-wide bus used by the package-level functions.
+var Default = New()
+
+func Subscribe(eventName string, name string, handler Handler) {
+    Default.Subscribe(eventName, name, handler)
+}
+
+func SubscribeAsync(eventName string, name string, handler Handler) {
+    Default.SubscribeAsync(eventName, name, handler)
+}
+
+func Publish(event Event) error {
+    return Default.Publish(event)
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+    "errors"
+    "github.com/stretchr/testify/assert"
+    "sync"
+    "testing"
+)
+
+func TestBusDeliversToSubscribersInOrder(t *testing.T) {
+    bus := New()
+    var mu sync.Mutex
+    calls := []string{}
+    record := func(name string) Handler {
+        return func(Event) error {
+            mu.Lock()
+            defer mu.Unlock()
+            calls = append(calls, name)
+            return nil
+        }
+    }
+
+    bus.SubscribeAsync(VoteCastEvent, "async", record("async"))
+    bus.Subscribe(VoteCastEvent, "first", record("first"))
+    bus.Subscribe(AllEvents, "all", record("all"))
+    bus.Subscribe(FavoriteAddedEvent, "other", record("other"))
+
+    assert.NoError(t, bus.Publish(VoteCast{ImageID: "abc"}))
+    bus.Wait()
+
+    assert.Equal(t, []string{"first", "all", "async"}, calls, "sync subscribers run before async ones")
+}
+
+func TestBusIsolatesFailingSubscribers(t *testing.T) {
+    bus := New()
+    delivered := 0
+    bus.Subscribe(FavoriteRemovedEvent, "broken", func(Event) error { return errors.New("boom") })
+    bus.Subscribe(FavoriteRemovedEvent, "panics", func(Event) error { panic("oops") })
+    bus.Subscribe(FavoriteRemovedEvent, "healthy", func(Event) error {
+        delivered++
+        return nil
+    })
+    bus.SubscribeAsync(FavoriteRemovedEvent, "async panics", func(Event) error { panic("oops") })
+
+    err := bus.Publish(FavoriteRemoved{ImageID: "abc"})
+    bus.Wait()
+
+    assert.Equal(t, 1, delivered)
+    assert.ErrorContains(t, err, "subscriber broken failed on favorite_removed: boom")
+    assert.ErrorContains(t, err, "subscriber panics panicked on favorite_removed: oops")
+}
+
+func TestBusPassesTypedEvents(t *testing.T) {
+    bus := New()
+    var got VoteCast
+    bus.Subscribe(VoteCastEvent, "typed", func(e Event) error {
+        got = e.(VoteCast)
+        return nil
+    })
+
+    bus.Publish(VoteCast{VoteID: "7", ImageID: "abc", Value: "love", BreedIDs: []string{"beng"}})
+
+    assert.Equal(t, "7", got.VoteID)
+    assert.Equal(t, []string{"beng"}, got.BreedIDs)
+}
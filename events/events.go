@@ -0,0 +1,79 @@
+// Package events is an in-process publish/subscribe bus for domain events.
+// Publishers such as the vote worker announce what happened; side effects
+// like leaderboards, webhooks, audit logs and live streams subscribe to the
+// events they care about without the publisher knowing about them.
+package events
+
+import (
+    "time"
+)
+
+// Event names, as returned by Event.EventName.
+const (
+    VoteCastEvent              = "vote_cast"
+    VoteRetractedEvent         = "vote_retracted"
+    FavoriteAddedEvent         = "favorite_added"
+    FavoriteRemovedEvent       = "favorite_removed"
+    BreedCatalogRefreshedEvent = "breed_catalog_refreshed"
+    CatOfTheDayChosenEvent     = "cat_of_the_day_chosen"
+)
+
+type Event interface {
+    EventName() string
+}
+
+type VoteCast struct {
+    VoteID   string    `json:"vote_id"`
+    UserID   string    `json:"user_id"`
+    ImageID  string    `json:"image_id"`
+    ImageURL string    `json:"image_url"`
+    BreedIDs []string  `json:"breed_ids,omitempty"`
+    Value    string    `json:"vote"`
+    CastAt   time.Time `json:"cast_at"`
+}
+
+// VoteRetracted is published when a vote is undone. CastAt is when the
+// vote was originally cast.
+type VoteRetracted struct {
+    VoteID      string    `json:"vote_id"`
+    UserID      string    `json:"user_id"`
+    ImageID     string    `json:"image_id"`
+    ImageURL    string    `json:"image_url"`
+    BreedIDs    []string  `json:"breed_ids,omitempty"`
+    Value       string    `json:"vote"`
+    CastAt      time.Time `json:"cast_at"`
+    RetractedAt time.Time `json:"retracted_at"`
+}
+
+type FavoriteAdded struct {
+    UserID   string    `json:"user_id"`
+    ImageID  string    `json:"image_id"`
+    ImageURL string    `json:"image_url"`
+    AddedAt  time.Time `json:"added_at"`
+}
+
+type FavoriteRemoved struct {
+    UserID    string    `json:"user_id"`
+    ImageID   string    `json:"image_id"`
+    RemovedAt time.Time `json:"removed_at"`
+}
+
+type BreedCatalogRefreshed struct {
+    BreedCount  int       `json:"breed_count"`
+    RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+type CatOfTheDayChosen struct {
+    Date     string    `json:"date"`
+    ImageID  string    `json:"image_id"`
+    ImageURL string    `json:"image_url"`
+    Reason   string    `json:"reason"`
+    ChosenAt time.Time `json:"chosen_at"`
+}
+
+func (VoteCast) EventName() string              { return VoteCastEvent }
+func (VoteRetracted) EventName() string         { return VoteRetractedEvent }
+func (FavoriteAdded) EventName() string         { return FavoriteAddedEvent }
+func (FavoriteRemoved) EventName() string       { return FavoriteRemovedEvent }
+func (BreedCatalogRefreshed) EventName() string { return BreedCatalogRefreshedEvent }
+func (CatOfTheDayChosen) EventName() string     { return CatOfTheDayChosenEvent }
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"CatVotingApp/controllers"
 	_ "CatVotingApp/routers"
+	_ "CatVotingApp/tasks"
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/task"
 )
 
 func init() {
@@ -19,5 +22,9 @@ func main() {
 		web.BConfig.WebConfig.StaticDir["/swagger"] = "swagger"
 	}
 
+	task.StartTask()
+	defer task.StopTask()
+	controllers.StartWebhookDispatcher()
+
 	web.Run()
 }
\ No newline at end of file
@@ -0,0 +1,229 @@
+package models
+
+import (
+    "fmt"
+    "time"
+)
+
+const (
+    FavoriteOpAdd     = "add"
+    FavoriteOpRemove  = "remove"
+    FavoriteOpRestore = "restore"
+
+    BatchOK      = "ok"
+    BatchFailed  = "failed"
+    BatchSkipped = "skipped"
+
+    maxBatchSize = 100
+)
+
+// FavoriteOp is one operation of a favorites batch. Add needs ID and URL;
+// remove moves the favorite to the trash and restore takes it back out.
+type FavoriteOp struct {
+    Op  string `json:"op"`
+    ID  string `json:"id"`
+    URL string `json:"url"`
+}
+
+// BatchItem is the outcome of one operation, in request order. In an
+// atomic batch that failed, operations that would have worked are skipped.
+type BatchItem struct {
+    Index  int    `json:"index"`
+    ID     string `json:"id"`
+    URL    string `json:"url,omitempty"`
+    Status string `json:"status"`
+    Error  string `json:"error,omitempty"`
+}
+
+// BatchResult sums up a batch. Cancelled is set when an atomic batch had a
+// failure and so changed nothing.
+type BatchResult struct {
+    Applied   int         `json:"applied"`
+    Failed    int         `json:"failed"`
+    Cancelled bool        `json:"cancelled"`
+    Results   []BatchItem `json:"results"`
+}
+
+// VoteBatchResult also lists the recorded votes, in request order.
+type VoteBatchResult struct {
+    BatchResult
+    Votes []Vote `json:"votes"`
+}
+
+// BatchFavorites applies the operations for userID with a single write.
+// When atomic, one failing operation cancels the whole batch; otherwise
+// the rest still apply.
+func BatchFavorites(userID string, ops []FavoriteOp, atomic bool) (*BatchResult, error) {
+    if err := checkBatchSize(len(ops)); err != nil {
+        return nil, err
+    }
+    images := make([]*Cat, len(ops))
+    for i, op := range ops {
+        if op.Op == FavoriteOpAdd {
+            images[i] = cachedImage(op.ID)
+        }
+    }
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    undo := snapshotFavorites()
+    now := time.Now()
+    result := &BatchResult{Results: make([]BatchItem, 0, len(ops))}
+    for i, op := range ops {
+        item := BatchItem{Index: i, ID: op.ID}
+        var err error
+        switch op.Op {
+        case FavoriteOpAdd:
+            if op.ID == "" {
+                err = fmt.Errorf("image id is required")
+            } else {
+                item.URL = op.URL
+                err = addFavorite(op.ID, op.URL, images[i])
+            }
+        case FavoriteOpRemove:
+            err = trashFavorite(userID, op.ID, now)
+        case FavoriteOpRestore:
+            var fav *FavoriteImage
+            if fav, err = restoreFavorite(op.ID); err == nil {
+                item.URL = fav.URL
+            }
+        default:
+            err = fmt.Errorf("unknown operation: %q", op.Op)
+        }
+        result.record(&item, err)
+    }
+
+    if result.Failed > 0 && atomic {
+        undo()
+        result.cancel()
+        return result, nil
+    }
+    if result.Applied > 0 {
+        if err := saveFavorites(); err != nil {
+            undo()
+            return nil, err
+        }
+    }
+    return result, nil
+}
+
+// BatchVotes records the votes for userID, adding loved images to the
+// favorites like a single vote does. When atomic, one invalid vote cancels
+// the whole batch; otherwise the rest are still recorded.
+func BatchVotes(userID string, reqs []VoteRequest, atomic bool) (*VoteBatchResult, error) {
+    if err := checkBatchSize(len(reqs)); err != nil {
+        return nil, err
+    }
+    images := make([]*Cat, len(reqs))
+    for i, req := range reqs {
+        if req.Vote == VoteLove {
+            images[i] = cachedImage(req.ImageID)
+        }
+    }
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    undo := snapshotFavorites()
+    result := &VoteBatchResult{BatchResult: BatchResult{Results: make([]BatchItem, 0, len(reqs))}, Votes: []Vote{}}
+    valid := []VoteRequest{}
+    loved := 0
+    for i, req := range reqs {
+        item := BatchItem{Index: i, ID: req.ImageID, URL: req.ImageURL}
+        err := req.Validate()
+        if err == nil && req.Vote == VoteLove {
+            if err = addFavorite(req.ImageID, req.ImageURL, images[i]); err == nil {
+                loved++
+            }
+        }
+        if err == nil {
+            valid = append(valid, req)
+        }
+        result.record(&item, err)
+    }
+
+    if result.Failed > 0 && atomic {
+        undo()
+        result.cancel()
+        return result, nil
+    }
+    if len(valid) == 0 {
+        return result, nil
+    }
+
+    var recorded []Vote
+    var err error
+    if loved > 0 {
+        recorded, err = saveFavoritesAndRecordVotes(userID, valid, undo)
+    } else {
+        recorded, err = recordVotes(userID, valid)
+    }
+    if err != nil {
+        return nil, err
+    }
+    result.Votes = recorded
+    return result, nil
+}
+
+func (r *BatchResult) record(item *BatchItem, err error) {
+    if err != nil {
+        item.Status, item.Error = BatchFailed, err.Error()
+        r.Failed++
+    } else {
+        item.Status = BatchOK
+        r.Applied++
+    }
+    r.Results = append(r.Results, *item)
+}
+
+func (r *BatchResult) cancel() {
+    for i := range r.Results {
+        if r.Results[i].Status == BatchOK {
+            r.Results[i].Status = BatchSkipped
+        }
+    }
+    r.Applied = 0
+    r.Cancelled = true
+}
+
+// snapshotFavorites returns a function that puts the favorites, trash and
+// collections back as they are now. Callers must hold favMutex.
+func snapshotFavorites() func() {
+    savedFavorites := append([]FavoriteImage(nil), favorites...)
+    savedTrash := append([]TrashedFavorite(nil), favoriteTrash...)
+    savedCollections := append([]Collection(nil), collections...)
+    savedSeq := collectionSeq
+    return func() {
+        favorites, favoriteTrash = savedFavorites, savedTrash
+        collections, collectionSeq = savedCollections, savedSeq
+    }
+}
+
+// saveFavoritesOrUndo saves the favorites, or puts them back with undo if
+// that fails. Callers must hold favMutex.
+func saveFavoritesOrUndo(undo func()) error {
+    if err := saveFavorites(); err != nil {
+        undo()
+        return err
+    }
+    return nil
+}
+
+func checkBatchSize(n int) error {
+    if n == 0 {
+        return fmt.Errorf("the batch is empty")
+    }
+    if n > maxBatchSize {
+        return fmt.Errorf("a batch can have at most %d operations", maxBatchSize)
+    }
+    return nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func batchStatuses(result *BatchResult) []string {
+    statuses := []string{}
+    for _, item := range result.Results {
+        statuses = append(statuses, item.Status)
+    }
+    return statuses
+}
+
+func TestBatchFavorites(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+
+    result, err := BatchFavorites("alice", []FavoriteOp{
+        {Op: FavoriteOpRemove, ID: "a"},
+        {Op: FavoriteOpRemove, ID: "missing"},
+        {Op: FavoriteOpAdd, ID: "c", URL: "http://example.com/c.jpg"},
+        {Op: FavoriteOpRestore, ID: "a"},
+        {Op: "rename", ID: "b"},
+    }, false)
+    assert.NoError(t, err)
+    assert.Equal(t, []string{BatchOK, BatchFailed, BatchOK, BatchOK, BatchFailed}, batchStatuses(result))
+    assert.Equal(t, 3, result.Applied)
+    assert.False(t, result.Cancelled)
+    assert.Equal(t, "http://example.com/a.jpg", result.Results[3].URL)
+    assert.Equal(t, []string{"b", "c", "a"}, favoriteIDs(GetFavorites()))
+}
+
+func TestBatchFavoritesAtomic(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+
+    result, err := BatchFavorites("alice", []FavoriteOp{
+        {Op: FavoriteOpRemove, ID: "a"},
+        {Op: FavoriteOpAdd, ID: "b", URL: "http://example.com/b.jpg"},
+    }, true)
+    assert.NoError(t, err)
+    assert.True(t, result.Cancelled)
+    assert.Equal(t, []string{BatchSkipped, BatchFailed}, batchStatuses(result))
+    assert.Equal(t, []string{"a", "b"}, favoriteIDs(GetFavorites()), "a cancelled batch changes nothing")
+    trash, _ := GetFavoriteTrash()
+    assert.Empty(t, trash)
+
+    result, err = BatchFavorites("alice", []FavoriteOp{
+        {Op: FavoriteOpRemove, ID: "a"},
+        {Op: FavoriteOpRemove, ID: "b"},
+    }, true)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, result.Applied)
+    assert.Empty(t, GetFavorites())
+
+    _, err = BatchFavorites("alice", nil, true)
+    assert.Error(t, err)
+    _, err = BatchFavorites("alice", make([]FavoriteOp, maxBatchSize+1), false)
+    assert.Error(t, err)
+}
+
+func TestBatchVotes(t *testing.T) {
+    resetCollections()
+    resetVotes()
+    defer resetVotes()
+    SaveFavorite("loved", "http://example.com/loved.jpg")
+
+    reqs := []VoteRequest{
+        {ImageID: "a", Vote: VoteLike},
+        {ImageID: "b", ImageURL: "http://example.com/b.jpg", Vote: VoteLove},
+        {ImageID: "loved", Vote: VoteLove},
+        {ImageID: "c", Vote: "meh"},
+    }
+
+    result, err := BatchVotes("alice", reqs, true)
+    assert.NoError(t, err)
+    assert.True(t, result.Cancelled)
+    assert.Equal(t, []string{BatchSkipped, BatchSkipped, BatchFailed, BatchFailed}, batchStatuses(&result.BatchResult))
+    assert.Empty(t, result.Votes)
+    assert.Empty(t, GetVotes(""))
+    assert.Equal(t, []string{"loved"}, favoriteIDs(GetFavorites()))
+
+    result, err = BatchVotes("alice", reqs, false)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, result.Applied)
+    assert.Equal(t, 2, len(result.Votes))
+    assert.Equal(t, "b", result.Votes[1].ImageID)
+    assert.Equal(t, 2, len(GetVotes("alice")))
+    assert.Equal(t, []string{"loved", "b"}, favoriteIDs(GetFavorites()))
+}
+
+func TestBatchVotesRecordsNothingWhenFavoritesCantBeSaved(t *testing.T) {
+    dir := withDataDir(t)
+    reloadVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    // A directory in the way makes saving the favorites fail.
+    assert.NoError(t, os.Mkdir(filepath.Join(dir, favoritesFile), 0755))
+
+    _, err := BatchVotes("alice", []VoteRequest{
+        {ImageID: "a", Vote: VoteLike},
+        {ImageID: "b", Vote: VoteLove},
+    }, true)
+    assert.Error(t, err)
+    assert.Empty(t, GetFavorites())
+    reloadVotes()
+    assert.Empty(t, GetVotes("alice"))
+}
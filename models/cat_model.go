@@ -2,32 +2,75 @@ package models
 
 import (
     "encoding/json"
+    "errors"
     "fmt"
+    "github.com/beego/beego/v2/core/logs"
     "github.com/beego/beego/v2/server/web"
     "io/ioutil"
     "net/http"
+    "net/url"
     "sync"
+    "time"
 )
 
 type Cat struct {
-    ID     string `json:"id"`
-    URL    string `json:"url"`
-    Breeds []Breed `json:"breeds"`
+    ID       string  `json:"id"`
+    URL      string  `json:"url"`
+    Width    int     `json:"width,omitempty"`
+    Height   int     `json:"height,omitempty"`
+    MimeType string  `json:"mime_type,omitempty"`
+    Breeds   []Breed `json:"breeds"`
 }
 
 type Breed struct {
     ID            string  `json:"id"`
     Name          string  `json:"name"`
+    AltNames      string  `json:"alt_names"`
     Description   string  `json:"description"`
     Origin        string  `json:"origin"`
+    Temperament   string  `json:"temperament"`
+    LifeSpan      string  `json:"life_span"`
+    Weight        BreedWeight `json:"weight"`
+    Indoor        int     `json:"indoor"`
+    Hypoallergenic int    `json:"hypoallergenic"`
+    Adaptability     int `json:"adaptability"`
+    AffectionLevel   int `json:"affection_level"`
+    ChildFriendly    int `json:"child_friendly"`
+    DogFriendly      int `json:"dog_friendly"`
+    EnergyLevel      int `json:"energy_level"`
+    Grooming         int `json:"grooming"`
+    HealthIssues     int `json:"health_issues"`
+    Intelligence     int `json:"intelligence"`
+    SheddingLevel    int `json:"shedding_level"`
+    SocialNeeds      int `json:"social_needs"`
+    StrangerFriendly int `json:"stranger_friendly"`
+    Vocalisation     int `json:"vocalisation"`
     WikipediaURL  string  `json:"wikipedia_url"`
     ReferenceImageID string `json:"reference_image_id"`
     Images          []string `json:"images,omitempty"`
 }
 
+type BreedWeight struct {
+    Imperial string `json:"imperial"`
+    Metric   string `json:"metric"`
+}
+
 type FavoriteImage struct {
     ID  string `json:"id"`
     URL string `json:"url"`
+    // Collection is the ID of the collection holding the image; empty means
+    // the default collection.
+    Collection string    `json:"collection,omitempty"`
+    Notes      string    `json:"notes,omitempty"`
+    Tags       []string  `json:"tags,omitempty"`
+    AddedAt    time.Time `json:"added_at"`
+    // Image is the full image record, when it is known. Favorites saved
+    // before it was recorded are backfilled from TheCatAPI.
+    Image *Cat `json:"image,omitempty"`
+    // BackfillFailures counts failed backfill lookups; the next one is not
+    // tried before BackfillRetryAt.
+    BackfillFailures int        `json:"backfill_failures,omitempty"`
+    BackfillRetryAt  *time.Time `json:"backfill_retry_at,omitempty"`
 }
 
 type VoteRequest struct {
@@ -57,9 +100,34 @@ func FetchCats() ([]Cat, error) {
     if err := json.Unmarshal(response, &cats); err != nil {
         return nil, err
     }
+    rememberCats(cats)
     return cats, nil
 }
 
+// FetchImage looks up a single image, with its breeds, by ID.
+func FetchImage(id string) (*Cat, error) {
+    catAPIURL, err := web.AppConfig.String("cat_api_url")
+    if err != nil {
+        return nil, err
+    }
+
+    imageURL := fmt.Sprintf("%s/images/%s", catAPIURL, url.PathEscape(id))
+    response, err := fetchFromAPI(imageURL)
+    if err != nil {
+        return nil, err
+    }
+
+    var cat Cat
+    if err := json.Unmarshal(response, &cat); err != nil {
+        return nil, err
+    }
+    if cat.ID == "" {
+        return nil, fmt.Errorf("image not found: %s", id)
+    }
+    rememberCats([]Cat{cat})
+    return &cat, nil
+}
+
 // Update the FetchBreeds function in your cat_model.go
 func FetchBreeds() ([]Breed, error) {
     catAPIURL, err := web.AppConfig.String("cat_api_url")
@@ -117,10 +185,10 @@ func FetchBreedDetails(breedId string) (*Breed, error) {
     apiKey, _ := web.AppConfig.String("cat_api_key")
     
     // First get breed details
-    url := fmt.Sprintf("%s/breeds/%s", catAPIURL, breedId)
+    breedURL := fmt.Sprintf("%s/breeds/%s", catAPIURL, url.PathEscape(breedId))
     
     client := &http.Client{}
-    req, err := http.NewRequest("GET", url, nil)
+    req, err := http.NewRequest("GET", breedURL, nil)
     if err != nil {
         return nil, fmt.Errorf("error creating request: %v", err)
     }
@@ -143,7 +211,7 @@ func FetchBreedDetails(breedId string) (*Breed, error) {
     }
     
     // Now fetch images for this breed
-    imagesURL := fmt.Sprintf("%s/images/search?breed_ids=%s&limit=5", catAPIURL, breedId)
+    imagesURL := fmt.Sprintf("%s/images/search?breed_ids=%s&limit=5", catAPIURL, url.QueryEscape(breedId))
     req, err = http.NewRequest("GET", imagesURL, nil)
     if err != nil {
         return nil, fmt.Errorf("error creating images request: %v", err)
@@ -178,48 +246,45 @@ func FetchBreedDetails(breedId string) (*Breed, error) {
 }
 
 func SaveFavorite(id string, url string) error {
+    image := cachedImage(id)
+
     favMutex.Lock()
     defer favMutex.Unlock()
-    
+
+    if err := loadFavorites(); err != nil {
+        return err
+    }
+    if err := addFavorite(id, url, image); err != nil {
+        return err
+    }
+    return saveFavorites()
+}
+
+// ErrAlreadyFavorite is returned when saving an image that is already a
+// favorite.
+var ErrAlreadyFavorite = errors.New("already in favorites")
+
+// addFavorite appends a favorite without saving. Callers must hold favMutex.
+func addFavorite(id string, url string, image *Cat) error {
     for _, fav := range favorites {
         if fav.ID == id {
-            return fmt.Errorf("already in favorites")
+            return ErrAlreadyFavorite
         }
     }
-    favorites = append(favorites, FavoriteImage{ID: id, URL: url})
+    favorites = append(favorites, FavoriteImage{ID: id, URL: url, AddedAt: time.Now(), Image: image})
     return nil
 }
 
 func GetFavorites() []FavoriteImage {
     favMutex.Lock()
     defer favMutex.Unlock()
-    return append([]FavoriteImage{}, favorites...)
-}
 
-func RemoveFavorite(id string) error {
-    favMutex.Lock()
-    defer favMutex.Unlock()
-    
-    // Log the incoming ID and current favorites for debugging
-    fmt.Printf("Attempting to remove favorite with ID: %s\n", id)
-    fmt.Printf("Current favorites: %+v\n", favorites)
-    
-    for i, fav := range favorites {
-        // Log each comparison
-        fmt.Printf("Comparing with favorite ID: %s\n", fav.ID)
-        
-        if fav.ID == id {
-            // Remove the item
-            favorites = append(favorites[:i], favorites[i+1:]...)
-            fmt.Printf("Successfully removed favorite with ID: %s\n", id)
-            return nil
-        }
+    if err := loadFavorites(); err != nil {
+        logs.Error("Error loading favorites: %v", err)
     }
-    
-    return fmt.Errorf("favorite not found with ID: %s", id)
+    return append([]FavoriteImage{}, favorites...)
 }
 
-
 func fetchFromAPI(url string) ([]byte, error) {
     apiKey, err := web.AppConfig.String("cat_api_key")
     if err != nil {
@@ -83,23 +83,6 @@ func TestSaveAndGetFavorites(t *testing.T) {
     assert.Equal(t, "already in favorites", err.Error())
 }
 
-func TestRemoveFavorite(t *testing.T) {
-    // Setup test data
-    favorites = []FavoriteImage{
-        {ID: "test123", URL: "http://example.com/cat.jpg"},
-    }
-    
-    // Test removing existing favorite
-    err := RemoveFavorite("test123")
-    assert.NoError(t, err)
-    assert.Equal(t, 0, len(GetFavorites()))
-    
-    // Test removing non-existent favorite
-    err = RemoveFavorite("nonexistent")
-    assert.Error(t, err)
-    assert.Contains(t, err.Error(), "favorite not found")
-}
-
 func TestFetchBreedDetails(t *testing.T) {
     breed, err := FetchBreedDetails("abys")
     assert.NoError(t, err)
@@ -0,0 +1,170 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+const (
+    catOfTheDayFile = "cat_of_the_day.json"
+    dateLayout      = "2006-01-02"
+
+    ReasonTopVoted      = "top_voted"
+    ReasonFavoriteBreed = "favorite_breed"
+    ReasonRandom        = "random"
+)
+
+type CatOfTheDay struct {
+    Date     string    `json:"date"`
+    Cat      Cat       `json:"cat"`
+    Reason   string    `json:"reason"`
+    Score    int       `json:"score,omitempty"`
+    ChosenAt time.Time `json:"chosen_at"`
+}
+
+var (
+    catOfTheDayHistory []CatOfTheDay // oldest first
+    catOfTheDayLoaded  bool
+    catOfTheDayMutex   sync.Mutex
+)
+
+// voteScore is how much each vote counts towards an image's daily score.
+var voteScore = map[string]int{
+    VoteLove:    2,
+    VoteLike:    1,
+    VoteDislike: -1,
+}
+
+// PickCatOfTheDay returns the featured cat for now's date, choosing and
+// persisting it if it has not been chosen yet. Once chosen, the pick stays
+// the same for the rest of the day, restarts included.
+func PickCatOfTheDay(now time.Time) (*CatOfTheDay, error) {
+    catOfTheDayMutex.Lock()
+    defer catOfTheDayMutex.Unlock()
+
+    if err := loadCatOfTheDay(); err != nil {
+        return nil, err
+    }
+
+    today := now.Format(dateLayout)
+    for i := len(catOfTheDayHistory) - 1; i >= 0; i-- {
+        if catOfTheDayHistory[i].Date == today {
+            pick := catOfTheDayHistory[i]
+            return &pick, nil
+        }
+    }
+
+    pick, err := chooseCatOfTheDay(now)
+    if err != nil {
+        return nil, err
+    }
+    pick.Date = today
+    pick.ChosenAt = now
+
+    catOfTheDayHistory = append(catOfTheDayHistory, *pick)
+    if err := saveJSON(catOfTheDayFile, catOfTheDayHistory); err != nil {
+        catOfTheDayHistory = catOfTheDayHistory[:len(catOfTheDayHistory)-1]
+        return nil, err
+    }
+    return pick, nil
+}
+
+// GetCatOfTheDay returns today's featured cat.
+func GetCatOfTheDay() (*CatOfTheDay, error) {
+    return PickCatOfTheDay(time.Now())
+}
+
+// GetCatOfTheDayHistory returns past picks, newest first.
+func GetCatOfTheDayHistory(limit int) ([]CatOfTheDay, error) {
+    catOfTheDayMutex.Lock()
+    defer catOfTheDayMutex.Unlock()
+
+    if err := loadCatOfTheDay(); err != nil {
+        return nil, err
+    }
+
+    history := make([]CatOfTheDay, 0, len(catOfTheDayHistory))
+    for i := len(catOfTheDayHistory) - 1; i >= 0; i-- {
+        history = append(history, catOfTheDayHistory[i])
+    }
+    if limit > 0 && len(history) > limit {
+        history = history[:limit]
+    }
+    return history, nil
+}
+
+// chooseCatOfTheDay prefers the best scoring image from yesterday's votes,
+// then a random image of the most loved breed, then any random image.
+func chooseCatOfTheDay(now time.Time) (*CatOfTheDay, error) {
+    yesterday := now.AddDate(0, 0, -1).Format(dateLayout)
+    scores := make(map[string]int)
+    latest := make(map[string]Vote)
+    for _, vote := range GetVotes("") {
+        if vote.CreatedAt.In(now.Location()).Format(dateLayout) != yesterday {
+            continue
+        }
+        scores[vote.ImageID] += voteScore[vote.Value]
+        latest[vote.ImageID] = vote
+    }
+
+    imageIDs := make([]string, 0, len(scores))
+    for id := range scores {
+        imageIDs = append(imageIDs, id)
+    }
+    sort.Slice(imageIDs, func(i, j int) bool {
+        if scores[imageIDs[i]] != scores[imageIDs[j]] {
+            return scores[imageIDs[i]] > scores[imageIDs[j]]
+        }
+        return imageIDs[i] < imageIDs[j]
+    })
+    if len(imageIDs) > 0 && scores[imageIDs[0]] > 0 {
+        vote := latest[imageIDs[0]]
+        cat, ok := LookupCat(vote.ImageID)
+        if !ok {
+            cat = Cat{ID: vote.ImageID, URL: vote.ImageURL}
+        }
+        return &CatOfTheDay{Cat: cat, Reason: ReasonTopVoted, Score: scores[vote.ImageID]}, nil
+    }
+
+    if breedID := favoriteBreed(); breedID != "" {
+        cats, err := fetchCatsByBreed(breedID, 1)
+        if err == nil && len(cats) > 0 {
+            return &CatOfTheDay{Cat: cats[0], Reason: ReasonFavoriteBreed}, nil
+        }
+    }
+
+    cats, err := FetchCats()
+    if err != nil {
+        return nil, err
+    }
+    if len(cats) == 0 {
+        return nil, fmt.Errorf("no cats available")
+    }
+    return &CatOfTheDay{Cat: cats[0], Reason: ReasonRandom}, nil
+}
+
+// favoriteBreed is the breed with the highest affinity across all users.
+func favoriteBreed() string {
+    best, bestScore := "", 0.0
+    for breedID, score := range BreedAffinity("") {
+        if score > bestScore || (score == bestScore && score > 0 && breedID < best) {
+            best, bestScore = breedID, score
+        }
+    }
+    return best
+}
+
+// loadCatOfTheDay reads the persisted history on first use. Callers must
+// hold catOfTheDayMutex.
+func loadCatOfTheDay() error {
+    if catOfTheDayLoaded {
+        return nil
+    }
+    if err := loadJSON(catOfTheDayFile, &catOfTheDayHistory); err != nil {
+        return err
+    }
+    catOfTheDayLoaded = true
+    return nil
+}
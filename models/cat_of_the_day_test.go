@@ -0,0 +1,83 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func resetCatOfTheDay() {
+    catOfTheDayMutex.Lock()
+    catOfTheDayHistory = nil
+    catOfTheDayLoaded = true
+    catOfTheDayMutex.Unlock()
+}
+
+func TestPickCatOfTheDayTopVoted(t *testing.T) {
+    resetVotes()
+    resetCatOfTheDay()
+    rememberCats([]Cat{{ID: "img1", URL: "http://example.com/1.jpg"}, {ID: "img2", URL: "http://example.com/2.jpg"}})
+
+    RecordVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLike})
+    RecordVote("bob", VoteRequest{ImageID: "img2", Vote: VoteLove})
+    RecordVote("carol", VoteRequest{ImageID: "img1", Vote: VoteDislike})
+
+    tomorrow := time.Now().AddDate(0, 0, 1)
+    pick, err := PickCatOfTheDay(tomorrow)
+    assert.NoError(t, err)
+    assert.Equal(t, "img2", pick.Cat.ID)
+    assert.Equal(t, ReasonTopVoted, pick.Reason)
+    assert.Equal(t, 2, pick.Score)
+
+    // The pick is stable for the day, even when the votes change...
+    RecordVote("dave", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    RecordVote("erin", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    pick, err = PickCatOfTheDay(tomorrow)
+    assert.NoError(t, err)
+    assert.Equal(t, "img2", pick.Cat.ID)
+
+    // ...and across restarts, which reload it from disk.
+    catOfTheDayMutex.Lock()
+    catOfTheDayHistory = nil
+    catOfTheDayLoaded = false
+    catOfTheDayMutex.Unlock()
+    pick, err = PickCatOfTheDay(tomorrow)
+    assert.NoError(t, err)
+    assert.Equal(t, "img2", pick.Cat.ID)
+
+    history, err := GetCatOfTheDayHistory(10)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(history))
+}
+
+func TestPickCatOfTheDayFallbacks(t *testing.T) {
+    resetVotes()
+    resetCatOfTheDay()
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        if breedID := r.URL.Query().Get("breed_ids"); breedID != "" {
+            w.Write([]byte(`[{"id":"fav-` + breedID + `"}]`))
+            return
+        }
+        w.Write([]byte(`[{"id":"random1"}]`))
+    })
+
+    now := time.Now()
+    pick, err := PickCatOfTheDay(now)
+    assert.NoError(t, err)
+    assert.Equal(t, ReasonRandom, pick.Reason)
+    assert.Equal(t, "random1", pick.Cat.ID)
+
+    // Votes from today don't count as yesterday's, so the next day falls
+    // back to the most loved breed.
+    rememberCats([]Cat{{ID: "b1", Breeds: []Breed{{ID: "beng"}}}})
+    RecordVote("alice", VoteRequest{ImageID: "b1", Vote: VoteLove})
+    pick, err = PickCatOfTheDay(now.AddDate(0, 0, 2))
+    assert.NoError(t, err)
+    assert.Equal(t, ReasonFavoriteBreed, pick.Reason)
+    assert.Equal(t, "fav-beng", pick.Cat.ID)
+
+    history, err := GetCatOfTheDayHistory(1)
+    assert.NoError(t, err)
+    assert.Equal(t, "fav-beng", history[0].Cat.ID)
+}
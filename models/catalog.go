@@ -0,0 +1,60 @@
+package models
+
+import (
+    "CatVotingApp/events"
+    "sync"
+    "time"
+)
+
+var (
+    breedCatalog []Breed
+    catalogMutex sync.RWMutex
+)
+
+// RefreshBreedCatalog fetches the breed list from TheCatAPI and replaces the
+// cached catalogue, rebuilding everything derived from it.
+func RefreshBreedCatalog() ([]Breed, error) {
+    breeds, err := FetchBreeds()
+    if err != nil {
+        return nil, err
+    }
+    SetBreedCatalog(breeds)
+    events.Publish(events.BreedCatalogRefreshed{BreedCount: len(breeds), RefreshedAt: time.Now()})
+    return breeds, nil
+}
+
+// SetBreedCatalog replaces the cached breed catalogue.
+func SetBreedCatalog(breeds []Breed) {
+    catalogMutex.Lock()
+    breedCatalog = append([]Breed{}, breeds...)
+    catalogMutex.Unlock()
+
+    rebuildSearchIndex(breeds)
+    rebuildSuggestIndex(breeds)
+}
+
+// GetBreedCatalog returns the cached catalogue, fetching it on first use.
+func GetBreedCatalog() ([]Breed, error) {
+    catalogMutex.RLock()
+    breeds := breedCatalog
+    catalogMutex.RUnlock()
+
+    if len(breeds) > 0 {
+        return append([]Breed{}, breeds...), nil
+    }
+    return RefreshBreedCatalog()
+}
+
+// cachedBreedName returns a breed's name from the cached catalogue without
+// fetching it, or "" when the catalogue hasn't been loaded.
+func cachedBreedName(id string) string {
+    catalogMutex.RLock()
+    defer catalogMutex.RUnlock()
+
+    for _, breed := range breedCatalog {
+        if breed.ID == id {
+            return breed.Name
+        }
+    }
+    return ""
+}
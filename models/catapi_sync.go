@@ -0,0 +1,497 @@
+package models
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/server/web"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "strconv"
+    "sync"
+    "time"
+)
+
+const (
+    catAPISyncFile = "catapi_sync.json"
+
+    SyncVote        = "vote"
+    SyncFavourite   = "favourite"
+    SyncUnfavourite = "unfavourite"
+    SyncUnvote      = "unvote"
+
+    catAPIPageSize = 100
+)
+
+// SyncOp is one local change waiting to be mirrored to TheCatAPI.
+type SyncOp struct {
+    ID          string    `json:"id"`
+    Kind        string    `json:"kind"`
+    ImageID     string    `json:"image_id"`
+    Value       int       `json:"value,omitempty"`
+    Attempts    int       `json:"attempts"`
+    NextAttempt time.Time `json:"next_attempt"`
+    LastError   string    `json:"last_error,omitempty"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+// catAPISyncState is the outbox plus the upstream favourite ID of each
+// synced image, which is what TheCatAPI needs to delete a favourite.
+type catAPISyncState struct {
+    Outbox     []SyncOp       `json:"outbox"`
+    Favourites map[string]int `json:"favourites"`
+    Seq        int            `json:"seq"`
+}
+
+// upstreamFavourite is a favourite as listed by TheCatAPI.
+type upstreamFavourite struct {
+    ID      int    `json:"id"`
+    ImageID string `json:"image_id"`
+    Image   struct {
+        ID  string `json:"id"`
+        URL string `json:"url"`
+    } `json:"image"`
+}
+
+// syncResult is the outcome of sending one op. permanent failures are
+// dropped instead of retried.
+type syncResult struct {
+    err         error
+    permanent   bool
+    favouriteID int
+}
+
+var (
+    catAPISync       catAPISyncState
+    catAPISyncLoaded bool
+    catAPISyncMutex  sync.Mutex
+    // syncInFlight holds the IDs of ops FlushCatAPISync is sending.
+    syncInFlight = make(map[string]bool)
+)
+
+// CatAPISyncEnabled reports whether local votes and favorites are mirrored
+// to TheCatAPI.
+func CatAPISyncEnabled() bool {
+    return web.AppConfig.DefaultBool("catapi_sync", false)
+}
+
+// catAPISubID identifies this app's votes and favourites upstream.
+func catAPISubID() string {
+    return web.AppConfig.DefaultString("catapi_sub_id", "CatVotingApp")
+}
+
+// QueueVoteSync queues a vote for TheCatAPI, which only knows up and down
+// votes: a love counts as an up vote.
+func QueueVoteSync(imageID string, vote string) error {
+    return queueSync(SyncOp{Kind: SyncVote, ImageID: imageID, Value: upstreamVoteValue(vote)})
+}
+
+// QueueUnvoteSync queues the upstream deletion of a retracted vote. Like
+// an unfavourite, a vote still waiting in the outbox is simply taken out.
+func QueueUnvoteSync(imageID string, vote string) error {
+    if !CatAPISyncEnabled() {
+        return nil
+    }
+
+    catAPISyncMutex.Lock()
+    defer catAPISyncMutex.Unlock()
+
+    if err := loadCatAPISync(); err != nil {
+        return err
+    }
+    value := upstreamVoteValue(vote)
+    for i := len(catAPISync.Outbox) - 1; i >= 0; i-- {
+        op := catAPISync.Outbox[i]
+        if op.Kind == SyncVote && op.ImageID == imageID && op.Value == value && op.Attempts == 0 && !syncInFlight[op.ID] {
+            catAPISync.Outbox = append(catAPISync.Outbox[:i:i], catAPISync.Outbox[i+1:]...)
+            return saveJSON(catAPISyncFile, catAPISync)
+        }
+    }
+    return appendSyncOp(SyncOp{Kind: SyncUnvote, ImageID: imageID, Value: value})
+}
+
+func upstreamVoteValue(vote string) int {
+    if vote == VoteDislike {
+        return -1
+    }
+    return 1
+}
+
+func QueueFavouriteSync(imageID string) error {
+    return queueSync(SyncOp{Kind: SyncFavourite, ImageID: imageID})
+}
+
+// QueueUnfavouriteSync queues the upstream deletion of a favourite. A
+// favourite that was never sent upstream is simply taken out of the outbox;
+// one that was, even unsuccessfully, may exist there and gets deleted.
+func QueueUnfavouriteSync(imageID string) error {
+    if !CatAPISyncEnabled() {
+        return nil
+    }
+
+    catAPISyncMutex.Lock()
+    defer catAPISyncMutex.Unlock()
+
+    if err := loadCatAPISync(); err != nil {
+        return err
+    }
+    for i, op := range catAPISync.Outbox {
+        if op.Kind == SyncFavourite && op.ImageID == imageID && op.Attempts == 0 && !syncInFlight[op.ID] {
+            catAPISync.Outbox = append(catAPISync.Outbox[:i:i], catAPISync.Outbox[i+1:]...)
+            return saveJSON(catAPISyncFile, catAPISync)
+        }
+    }
+    return appendSyncOp(SyncOp{Kind: SyncUnfavourite, ImageID: imageID})
+}
+
+// GetSyncOutbox returns the changes not yet mirrored upstream, oldest first.
+func GetSyncOutbox() ([]SyncOp, error) {
+    catAPISyncMutex.Lock()
+    defer catAPISyncMutex.Unlock()
+
+    if err := loadCatAPISync(); err != nil {
+        return nil, err
+    }
+    return append([]SyncOp{}, catAPISync.Outbox...), nil
+}
+
+// FlushCatAPISync sends every due op upstream, in the order they were
+// queued. Ops that fail with a server or network error are retried with
+// exponential backoff starting at catapi_sync_backoff and dropped after
+// catapi_sync_max_attempts; ops TheCatAPI rejects outright are dropped
+// straight away. It returns how many ops were mirrored.
+func FlushCatAPISync(now time.Time) (int, error) {
+    catAPISyncMutex.Lock()
+    if err := loadCatAPISync(); err != nil {
+        catAPISyncMutex.Unlock()
+        return 0, err
+    }
+    favourites := make(map[string]int, len(catAPISync.Favourites))
+    for imageID, id := range catAPISync.Favourites {
+        favourites[imageID] = id
+    }
+    due := []SyncOp{}
+    for _, op := range catAPISync.Outbox {
+        if !op.NextAttempt.After(now) {
+            due = append(due, op)
+            syncInFlight[op.ID] = true
+        }
+    }
+    catAPISyncMutex.Unlock()
+
+    if len(due) == 0 {
+        return 0, nil
+    }
+
+    // Talk to TheCatAPI without holding the lock.
+    results := make(map[string]syncResult, len(due))
+    for _, op := range due {
+        result := sendSyncOp(op, favourites)
+        if result.err == nil && op.Kind == SyncFavourite {
+            favourites[op.ImageID] = result.favouriteID
+        }
+        results[op.ID] = result
+    }
+
+    catAPISyncMutex.Lock()
+    defer catAPISyncMutex.Unlock()
+
+    maxAttempts := web.AppConfig.DefaultInt("catapi_sync_max_attempts", 10)
+    synced := 0
+    outbox := catAPISync.Outbox[:0:0]
+    for _, op := range catAPISync.Outbox {
+        result, attempted := results[op.ID]
+        delete(syncInFlight, op.ID)
+        if !attempted {
+            outbox = append(outbox, op)
+            continue
+        }
+
+        op.Attempts++
+        switch {
+        case result.err == nil:
+            synced++
+            switch op.Kind {
+            case SyncFavourite:
+                catAPISync.Favourites[op.ImageID] = result.favouriteID
+            case SyncUnfavourite:
+                delete(catAPISync.Favourites, op.ImageID)
+            }
+        case result.permanent || op.Attempts >= maxAttempts:
+            logs.Error("Dropping TheCatAPI %s sync of %s after %d attempts: %v", op.Kind, op.ImageID, op.Attempts, result.err)
+        default:
+            op.LastError = result.err.Error()
+            op.NextAttempt = now.Add(retryBackoff("catapi_sync_backoff", time.Minute, op.Attempts))
+            outbox = append(outbox, op)
+        }
+    }
+    catAPISync.Outbox = outbox
+    return synced, saveJSON(catAPISyncFile, catAPISync)
+}
+
+// ImportCatAPIFavourites adds the favourites stored upstream under our
+// sub_id to the local favorites, so they survive restarts. It returns how
+// many favorites were new.
+func ImportCatAPIFavourites() (int, error) {
+    imported := []upstreamFavourite{}
+    for page := 0; ; page++ {
+        query := url.Values{}
+        query.Set("sub_id", catAPISubID())
+        query.Set("limit", strconv.Itoa(catAPIPageSize))
+        query.Set("page", strconv.Itoa(page))
+
+        body, status, err := catAPIRequest("GET", "/favourites?"+query.Encode(), nil)
+        if err != nil {
+            return 0, err
+        }
+        if status != http.StatusOK {
+            return 0, fmt.Errorf("API request failed: %d", status)
+        }
+
+        var favourites []upstreamFavourite
+        if err := json.Unmarshal(body, &favourites); err != nil {
+            return 0, err
+        }
+        imported = append(imported, favourites...)
+        if len(favourites) < catAPIPageSize {
+            break
+        }
+    }
+
+    catAPISyncMutex.Lock()
+    if err := loadCatAPISync(); err != nil {
+        catAPISyncMutex.Unlock()
+        return 0, err
+    }
+    for _, favourite := range imported {
+        catAPISync.Favourites[favourite.ImageID] = favourite.ID
+    }
+    err := saveJSON(catAPISyncFile, catAPISync)
+    catAPISyncMutex.Unlock()
+    if err != nil {
+        return 0, err
+    }
+
+    added := 0
+    for _, favourite := range imported {
+        // Already being a favorite is fine: that's what we're syncing.
+        if SaveFavorite(favourite.ImageID, favourite.Image.URL) == nil {
+            added++
+        }
+    }
+    return added, nil
+}
+
+func queueSync(op SyncOp) error {
+    if !CatAPISyncEnabled() {
+        return nil
+    }
+
+    catAPISyncMutex.Lock()
+    defer catAPISyncMutex.Unlock()
+
+    if err := loadCatAPISync(); err != nil {
+        return err
+    }
+    return appendSyncOp(op)
+}
+
+// appendSyncOp adds op to the outbox, due now. Callers must hold
+// catAPISyncMutex.
+func appendSyncOp(op SyncOp) error {
+    catAPISync.Seq++
+    op.ID = strconv.Itoa(catAPISync.Seq)
+    op.CreatedAt = time.Now()
+    op.NextAttempt = op.CreatedAt
+    catAPISync.Outbox = append(catAPISync.Outbox, op)
+    if err := saveJSON(catAPISyncFile, catAPISync); err != nil {
+        catAPISync.Outbox = catAPISync.Outbox[:len(catAPISync.Outbox)-1]
+        return err
+    }
+    return nil
+}
+
+func sendSyncOp(op SyncOp, favourites map[string]int) syncResult {
+    switch op.Kind {
+    case SyncVote:
+        _, status, err := catAPIRequest("POST", "/votes", map[string]interface{}{
+            "image_id": op.ImageID,
+            "sub_id":   catAPISubID(),
+            "value":    op.Value,
+        })
+        return syncOutcome(status, err)
+
+    case SyncFavourite:
+        body, status, err := catAPIRequest("POST", "/favourites", map[string]interface{}{
+            "image_id": op.ImageID,
+            "sub_id":   catAPISubID(),
+        })
+        result := syncOutcome(status, err)
+        if result.err == nil {
+            var created struct {
+                ID int `json:"id"`
+            }
+            json.Unmarshal(body, &created)
+            result.favouriteID = created.ID
+        }
+        return result
+
+    case SyncUnfavourite:
+        id, ok := favourites[op.ImageID]
+        if !ok {
+            var err error
+            if id, ok, err = findUpstreamFavourite(op.ImageID); err != nil {
+                return syncResult{err: err}
+            }
+        }
+        if !ok {
+            // Nothing upstream to delete.
+            return syncResult{}
+        }
+        _, status, err := catAPIRequest("DELETE", fmt.Sprintf("/favourites/%d", id), nil)
+        if status == http.StatusNotFound {
+            return syncResult{}
+        }
+        return syncOutcome(status, err)
+
+    case SyncUnvote:
+        id, ok, err := findUpstreamVote(op.ImageID, op.Value)
+        if err != nil {
+            return syncResult{err: err}
+        }
+        if !ok {
+            return syncResult{}
+        }
+        _, status, err := catAPIRequest("DELETE", fmt.Sprintf("/votes/%d", id), nil)
+        if status == http.StatusNotFound {
+            return syncResult{}
+        }
+        return syncOutcome(status, err)
+    }
+    return syncResult{err: fmt.Errorf("unknown sync op: %s", op.Kind), permanent: true}
+}
+
+func findUpstreamFavourite(imageID string) (int, bool, error) {
+    query := url.Values{}
+    query.Set("sub_id", catAPISubID())
+    query.Set("image_id", imageID)
+
+    body, status, err := catAPIRequest("GET", "/favourites?"+query.Encode(), nil)
+    if result := syncOutcome(status, err); result.err != nil {
+        return 0, false, result.err
+    }
+
+    var favourites []upstreamFavourite
+    if err := json.Unmarshal(body, &favourites); err != nil {
+        return 0, false, err
+    }
+    for _, favourite := range favourites {
+        if favourite.ImageID == imageID {
+            return favourite.ID, true, nil
+        }
+    }
+    return 0, false, nil
+}
+
+// findUpstreamVote finds the latest of our votes on the image with the
+// given value. Upstream votes carry no user, so any of them will do.
+func findUpstreamVote(imageID string, value int) (int, bool, error) {
+    found := 0
+    for page := 0; ; page++ {
+        query := url.Values{}
+        query.Set("sub_id", catAPISubID())
+        query.Set("limit", strconv.Itoa(catAPIPageSize))
+        query.Set("page", strconv.Itoa(page))
+
+        body, status, err := catAPIRequest("GET", "/votes?"+query.Encode(), nil)
+        if result := syncOutcome(status, err); result.err != nil {
+            return 0, false, result.err
+        }
+
+        var upstream []struct {
+            ID      int    `json:"id"`
+            ImageID string `json:"image_id"`
+            Value   int    `json:"value"`
+        }
+        if err := json.Unmarshal(body, &upstream); err != nil {
+            return 0, false, err
+        }
+        for _, vote := range upstream {
+            if vote.ImageID == imageID && vote.Value == value && vote.ID > found {
+                found = vote.ID
+            }
+        }
+        if len(upstream) < catAPIPageSize {
+            return found, found != 0, nil
+        }
+    }
+}
+
+// syncOutcome classifies a response: client errors other than rate limiting
+// won't succeed on retry.
+func syncOutcome(status int, err error) syncResult {
+    switch {
+    case err != nil:
+        return syncResult{err: err}
+    case status >= 200 && status < 300:
+        return syncResult{}
+    case status >= 400 && status < 500 && status != http.StatusTooManyRequests:
+        return syncResult{err: fmt.Errorf("API request failed: %d", status), permanent: true}
+    }
+    return syncResult{err: fmt.Errorf("API request failed: %d", status)}
+}
+
+func catAPIRequest(method, path string, payload interface{}) ([]byte, int, error) {
+    catAPIURL, err := web.AppConfig.String("cat_api_url")
+    if err != nil {
+        return nil, 0, err
+    }
+    apiKey, _ := web.AppConfig.String("cat_api_key")
+
+    var body io.Reader
+    if payload != nil {
+        data, err := json.Marshal(payload)
+        if err != nil {
+            return nil, 0, err
+        }
+        body = bytes.NewReader(data)
+    }
+
+    req, err := http.NewRequest(method, catAPIURL+path, body)
+    if err != nil {
+        return nil, 0, err
+    }
+    req.Header.Set("x-api-key", apiKey)
+    if payload != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+
+    client := &http.Client{Timeout: 10 * time.Second}
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer resp.Body.Close()
+
+    data, err := ioutil.ReadAll(resp.Body)
+    return data, resp.StatusCode, err
+}
+
+// loadCatAPISync reads the persisted outbox on first use. Callers must hold
+// catAPISyncMutex.
+func loadCatAPISync() error {
+    if catAPISyncLoaded {
+        return nil
+    }
+    if err := loadJSON(catAPISyncFile, &catAPISync); err != nil {
+        return err
+    }
+    if catAPISync.Favourites == nil {
+        catAPISync.Favourites = make(map[string]int)
+    }
+    catAPISyncLoaded = true
+    return nil
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+    "encoding/json"
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "sync"
+    "testing"
+    "time"
+)
+
+func resetCatAPISync() {
+    catAPISyncMutex.Lock()
+    catAPISync = catAPISyncState{Favourites: make(map[string]int)}
+    catAPISyncLoaded = true
+    catAPISyncMutex.Unlock()
+}
+
+func resetFavorites() {
+    favMutex.Lock()
+    favorites = []FavoriteImage{}
+    favoriteTrash = nil
+    favoritesLoaded = true
+    favMutex.Unlock()
+}
+
+func withCatAPISync(t *testing.T) {
+    web.AppConfig.Set("catapi_sync", "true")
+    web.AppConfig.Set("catapi_sub_id", "tester")
+    t.Cleanup(func() {
+        web.AppConfig.Set("catapi_sync", "false")
+        web.AppConfig.Set("catapi_sub_id", "CatVotingApp")
+    })
+    resetCatAPISync()
+}
+
+func TestCatAPISyncDisabled(t *testing.T) {
+    resetCatAPISync()
+    assert.NoError(t, QueueVoteSync("abc", VoteLike))
+    outbox, _ := GetSyncOutbox()
+    assert.Empty(t, outbox)
+}
+
+func TestFlushCatAPISync(t *testing.T) {
+    withCatAPISync(t)
+    var mu sync.Mutex
+    requests := []string{}
+    votes := []map[string]interface{}{}
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        defer mu.Unlock()
+        requests = append(requests, r.Method+" "+r.URL.Path)
+        switch {
+        case r.Method == "POST" && r.URL.Path == "/votes":
+            var body map[string]interface{}
+            json.NewDecoder(r.Body).Decode(&body)
+            votes = append(votes, body)
+        case r.Method == "POST" && r.URL.Path == "/favourites":
+            w.Write([]byte(`{"message":"SUCCESS","id":42}`))
+        case r.Method == "DELETE" && r.URL.Path == "/favourites/42":
+        default:
+            w.WriteHeader(http.StatusInternalServerError)
+        }
+    })
+
+    QueueVoteSync("abc", VoteDislike)
+    QueueFavouriteSync("abc")
+    synced, err := FlushCatAPISync(time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, 2, synced)
+    assert.Equal(t, "tester", votes[0]["sub_id"])
+    assert.Equal(t, -1.0, votes[0]["value"])
+
+    QueueUnfavouriteSync("abc")
+    synced, _ = FlushCatAPISync(time.Now())
+    assert.Equal(t, 1, synced)
+    assert.Equal(t, []string{"POST /votes", "POST /favourites", "DELETE /favourites/42"}, requests)
+
+    outbox, _ := GetSyncOutbox()
+    assert.Empty(t, outbox)
+}
+
+func TestFlushCatAPISyncRetries(t *testing.T) {
+    withCatAPISync(t)
+    status := http.StatusServiceUnavailable
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(status)
+    })
+
+    QueueVoteSync("abc", VoteLove)
+    now := time.Now()
+    synced, err := FlushCatAPISync(now)
+    assert.NoError(t, err)
+    assert.Equal(t, 0, synced)
+
+    outbox, _ := GetSyncOutbox()
+    assert.Equal(t, 1, outbox[0].Attempts)
+    assert.Equal(t, now.Add(time.Minute), outbox[0].NextAttempt)
+
+    // Client errors won't get better with retries.
+    status = http.StatusBadRequest
+    FlushCatAPISync(now.Add(time.Minute))
+    outbox, _ = GetSyncOutbox()
+    assert.Empty(t, outbox)
+}
+
+func TestUnfavouriteCancelsPendingFavourite(t *testing.T) {
+    withCatAPISync(t)
+
+    QueueFavouriteSync("abc")
+    QueueUnfavouriteSync("abc")
+
+    outbox, _ := GetSyncOutbox()
+    assert.Empty(t, outbox, "a favourite that never went upstream needs no deletion")
+}
+
+func TestUnvoteSync(t *testing.T) {
+    withCatAPISync(t)
+    var mu sync.Mutex
+    requests := []string{}
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        defer mu.Unlock()
+        requests = append(requests, r.Method+" "+r.URL.Path)
+        if r.Method == "GET" && r.URL.Path == "/votes" {
+            assert.Equal(t, "tester", r.URL.Query().Get("sub_id"))
+            w.Write([]byte(`[
+                {"id": 7, "image_id": "abc", "value": 1},
+                {"id": 8, "image_id": "abc", "value": -1},
+                {"id": 9, "image_id": "xyz", "value": 1}
+            ]`))
+        }
+    })
+
+    // A vote that never went upstream is just taken out of the outbox.
+    QueueVoteSync("abc", VoteLike)
+    QueueUnvoteSync("abc", VoteLike)
+    outbox, _ := GetSyncOutbox()
+    assert.Empty(t, outbox)
+
+    QueueUnvoteSync("abc", VoteLove)
+    synced, err := FlushCatAPISync(time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, 1, synced)
+    assert.Equal(t, []string{"GET /votes", "DELETE /votes/7"}, requests)
+}
+
+func TestImportCatAPIFavourites(t *testing.T) {
+    withCatAPISync(t)
+    resetFavorites()
+    defer resetFavorites()
+    SaveFavorite("existing", "http://example.com/existing.jpg")
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        assert.Equal(t, "tester", r.URL.Query().Get("sub_id"))
+        w.Write([]byte(`[
+            {"id": 1, "image_id": "existing", "image": {"id": "existing", "url": "http://example.com/existing.jpg"}},
+            {"id": 2, "image_id": "new", "image": {"id": "new", "url": "http://example.com/new.jpg"}}
+        ]`))
+    })
+
+    added, err := ImportCatAPIFavourites()
+    assert.NoError(t, err)
+    assert.Equal(t, 1, added)
+    assert.Equal(t, 2, len(GetFavorites()))
+    assert.Equal(t, 2, catAPISync.Favourites["new"])
+}
@@ -0,0 +1,241 @@
+package models
+
+import (
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// DefaultCollection holds every favorite that hasn't been put anywhere
+// else. It always exists and can't be renamed or deleted.
+const (
+    DefaultCollection     = "default"
+    defaultCollectionName = "Favorites"
+)
+
+// Collection is a named album of favorites. Each favorite belongs to
+// exactly one collection.
+type Collection struct {
+    ID        string    `json:"id"`
+    Name      string    `json:"name"`
+    Count     int       `json:"count"`
+    CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Collections share favMutex with the favorites they hold.
+var (
+    collections   []Collection
+    collectionSeq int
+)
+
+// GetCollections lists the default collection followed by the others in
+// the order they were created, each with its image count.
+func GetCollections() []Collection {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        logs.Error("Error loading favorites: %v", err)
+    }
+
+    counts := make(map[string]int)
+    for _, fav := range favorites {
+        counts[collectionOf(fav)]++
+    }
+
+    result := []Collection{{ID: DefaultCollection, Name: defaultCollectionName, Count: counts[DefaultCollection]}}
+    for _, collection := range collections {
+        collection.Count = counts[collection.ID]
+        result = append(result, collection)
+    }
+    return result
+}
+
+func CreateCollection(name string) (*Collection, error) {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    name, err := validCollectionName(name, "")
+    if err != nil {
+        return nil, err
+    }
+
+    undo := snapshotFavorites()
+    collectionSeq++
+    collection := Collection{ID: strconv.Itoa(collectionSeq), Name: name, CreatedAt: time.Now()}
+    collections = append(collections, collection)
+    if err := saveFavoritesOrUndo(undo); err != nil {
+        return nil, err
+    }
+    return &collection, nil
+}
+
+func RenameCollection(id string, name string) (*Collection, error) {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    i, err := findCollection(id)
+    if err != nil {
+        return nil, err
+    }
+    if name, err = validCollectionName(name, id); err != nil {
+        return nil, err
+    }
+
+    undo := snapshotFavorites()
+    collections[i].Name = name
+    collection := collections[i]
+    if err := saveFavoritesOrUndo(undo); err != nil {
+        return nil, err
+    }
+    return &collection, nil
+}
+
+// DeleteCollection removes a collection. Its images aren't lost: they go
+// back to the default collection.
+func DeleteCollection(id string) error {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return err
+    }
+
+    i, err := findCollection(id)
+    if err != nil {
+        return err
+    }
+
+    undo := snapshotFavorites()
+    collections = append(collections[:i:i], collections[i+1:]...)
+    for j := range favorites {
+        if favorites[j].Collection == id {
+            favorites[j].Collection = ""
+        }
+    }
+    return saveFavoritesOrUndo(undo)
+}
+
+// AddToCollection puts an image into a collection, moving it out of the one
+// it was in. An image that isn't a favorite yet becomes one, which is
+// reported by the returned flag.
+func AddToCollection(id string, imageID string, imageURL string) (bool, error) {
+    image := cachedImage(imageID)
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return false, err
+    }
+
+    if id != DefaultCollection {
+        if _, err := findCollection(id); err != nil {
+            return false, err
+        }
+    } else {
+        id = ""
+    }
+
+    undo := snapshotFavorites()
+    for i := range favorites {
+        if favorites[i].ID == imageID {
+            favorites[i].Collection = id
+            return false, saveFavoritesOrUndo(undo)
+        }
+    }
+
+    if imageURL == "" {
+        return false, fmt.Errorf("image url is required for a new favorite")
+    }
+    if _, err := checkImageURL(imageURL); err != nil {
+        return false, err
+    }
+    favorites = append(favorites, FavoriteImage{
+        ID:         imageID,
+        URL:        imageURL,
+        Collection: id,
+        AddedAt:    time.Now(),
+        Image:      image,
+    })
+    if err := saveFavoritesOrUndo(undo); err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// RemoveFromCollection takes an image out of a collection. It stays a
+// favorite, in the default collection.
+func RemoveFromCollection(id string, imageID string) error {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return err
+    }
+
+    if id == DefaultCollection {
+        return fmt.Errorf("images can't be removed from the default collection")
+    }
+    if _, err := findCollection(id); err != nil {
+        return err
+    }
+
+    for i := range favorites {
+        if favorites[i].ID == imageID && favorites[i].Collection == id {
+            undo := snapshotFavorites()
+            favorites[i].Collection = ""
+            return saveFavoritesOrUndo(undo)
+        }
+    }
+    return fmt.Errorf("image %s is not in collection %s", imageID, id)
+}
+
+func collectionOf(fav FavoriteImage) string {
+    if fav.Collection == "" {
+        return DefaultCollection
+    }
+    return fav.Collection
+}
+
+// findCollection returns the index of a user-created collection. Callers
+// must hold favMutex.
+func findCollection(id string) (int, error) {
+    if id == DefaultCollection {
+        return -1, fmt.Errorf("the default collection can't be changed")
+    }
+    for i, collection := range collections {
+        if collection.ID == id {
+            return i, nil
+        }
+    }
+    return -1, fmt.Errorf("collection not found: %s", id)
+}
+
+// validCollectionName trims the name and checks it is not empty and not
+// already used by another collection. Callers must hold favMutex.
+func validCollectionName(name string, id string) (string, error) {
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return "", fmt.Errorf("collection name is required")
+    }
+    if strings.EqualFold(name, defaultCollectionName) {
+        return "", fmt.Errorf("collection already exists: %s", name)
+    }
+    for _, collection := range collections {
+        if collection.ID != id && strings.EqualFold(collection.Name, name) {
+            return "", fmt.Errorf("collection already exists: %s", name)
+        }
+    }
+    return name, nil
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func resetCollections() {
+    resetFavorites()
+    favMutex.Lock()
+    collections = nil
+    favMutex.Unlock()
+}
+
+func TestCollections(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+
+    desk, err := CreateCollection("  Desk wallpapers ")
+    assert.NoError(t, err)
+    assert.Equal(t, "Desk wallpapers", desk.Name)
+    _, err = CreateCollection("desk WALLPAPERS")
+    assert.Error(t, err, "names are unique regardless of case")
+    _, err = CreateCollection("")
+    assert.Error(t, err)
+
+    mascots, _ := CreateCollection("Team mascots")
+
+    added, err := AddToCollection(desk.ID, "a", "")
+    assert.NoError(t, err)
+    assert.False(t, added)
+    added, err = AddToCollection(mascots.ID, "c", "https://cdn2.thecatapi.com/images/c.jpg")
+    assert.NoError(t, err)
+    assert.True(t, added, "adding a new image makes it a favorite")
+    _, err = AddToCollection(mascots.ID, "d", "")
+    assert.Error(t, err)
+    _, err = AddToCollection(mascots.ID, "d", "http://169.254.169.254/latest/meta-data")
+    assert.Error(t, err, "new favorites must come from an image host")
+
+    // Moving an image takes it out of its old collection.
+    AddToCollection(mascots.ID, "a", "")
+    inDesk, _ := QueryFavorites(FavoriteQuery{Collection: desk.ID})
+    assert.Empty(t, inDesk)
+    inMascots, _ := QueryFavorites(FavoriteQuery{Collection: mascots.ID})
+    assert.Equal(t, []string{"a", "c"}, favoriteIDs(inMascots))
+    inDefault, _ := QueryFavorites(FavoriteQuery{Collection: DefaultCollection})
+    assert.Equal(t, []string{"b"}, favoriteIDs(inDefault))
+    assert.Equal(t, 3, len(GetFavorites()), "the unfiltered list is unchanged")
+
+    assert.Error(t, RemoveFromCollection(desk.ID, "a"))
+    assert.NoError(t, RemoveFromCollection(mascots.ID, "a"))
+
+    renamed, err := RenameCollection(mascots.ID, "Mascots")
+    assert.NoError(t, err)
+    assert.Equal(t, "Mascots", renamed.Name)
+    _, err = RenameCollection(DefaultCollection, "Other")
+    assert.Error(t, err)
+
+    assert.NoError(t, DeleteCollection(mascots.ID))
+    _, err = QueryFavorites(FavoriteQuery{Collection: mascots.ID})
+    assert.Error(t, err)
+
+    list := GetCollections()
+    assert.Equal(t, 2, len(list))
+    assert.Equal(t, DefaultCollection, list[0].ID)
+    assert.Equal(t, 3, list[0].Count, "images of a deleted collection go back to the default one")
+}
+
+func favoriteIDs(favs []FavoriteImage) []string {
+    ids := []string{}
+    for _, fav := range favs {
+        ids = append(ids, fav.ID)
+    }
+    return ids
+}
+
+func TestCollectionChangesAreUndoneWhenTheSaveFails(t *testing.T) {
+    dir := withDataDir(t)
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    desk, _ := CreateCollection("Desk")
+
+    // A directory in the way makes saving the favorites fail.
+    assert.NoError(t, os.Mkdir(filepath.Join(dir, favoritesFile+".tmp"), 0755))
+
+    _, err := CreateCollection("Mascots")
+    assert.Error(t, err)
+    _, err = RenameCollection(desk.ID, "Office")
+    assert.Error(t, err)
+    _, err = AddToCollection(desk.ID, "a", "")
+    assert.Error(t, err)
+    _, err = AddToCollection(desk.ID, "b", "https://cdn2.thecatapi.com/images/b.jpg")
+    assert.Error(t, err)
+    assert.Error(t, DeleteCollection(desk.ID))
+
+    collections := GetCollections()
+    assert.Equal(t, 2, len(collections))
+    assert.Equal(t, "Desk", collections[1].Name)
+    assert.Equal(t, 1, collections[0].Count)
+    assert.Equal(t, []string{"a"}, favoriteIDs(GetFavorites()))
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+    "fmt"
+    "github.com/beego/beego/v2/server/web"
+    "sync"
+)
+
+type ComparedBreed struct {
+    ID       string         `json:"id"`
+    Name     string         `json:"name"`
+    Origin   string         `json:"origin"`
+    LifeSpan Range          `json:"life_span_years"`
+    Weight   Range          `json:"weight_kg"`
+    Traits   map[string]int `json:"traits"`
+    ImageURL string         `json:"image_url,omitempty"`
+}
+
+type BreedComparison struct {
+    Traits []Trait           `json:"traits"`
+    Breeds []ComparedBreed   `json:"breeds"`
+    Errors map[string]string `json:"errors"`
+}
+
+// CompareBreeds fetches the requested breeds concurrently, at most
+// compare_concurrency at a time, and lines them up in one table. A breed
+// that fails to load is reported in Errors instead of failing the whole
+// comparison.
+func CompareBreeds(ids []string) *BreedComparison {
+    details := make([]*Breed, len(ids))
+    errs := make([]error, len(ids))
+
+    concurrency := web.AppConfig.DefaultInt("compare_concurrency", 4)
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    sem := make(chan struct{}, concurrency)
+    var wg sync.WaitGroup
+    for i, id := range ids {
+        wg.Add(1)
+        go func(i int, id string) {
+            defer wg.Done()
+            sem <- struct{}{}
+            defer func() { <-sem }()
+
+            breed, err := FetchBreedDetails(id)
+            if err == nil && breed.ID == "" {
+                err = fmt.Errorf("breed not found: %s", id)
+            }
+            details[i], errs[i] = breed, err
+        }(i, id)
+    }
+    wg.Wait()
+
+    comparison := &BreedComparison{
+        Traits: BreedTraits(),
+        Breeds: []ComparedBreed{},
+        Errors: map[string]string{},
+    }
+    for i, id := range ids {
+        if errs[i] != nil {
+            comparison.Errors[id] = errs[i].Error()
+            continue
+        }
+
+        breed := details[i]
+        compared := ComparedBreed{
+            ID:       breed.ID,
+            Name:     breed.Name,
+            Origin:   breed.Origin,
+            LifeSpan: parseRange(breed.LifeSpan),
+            Weight:   parseRange(breed.Weight.Metric),
+            Traits:   TraitScores(*breed),
+        }
+        if len(breed.Images) > 0 {
+            compared.ImageURL = breed.Images[0]
+        }
+        comparison.Breeds = append(comparison.Breeds, compared)
+    }
+    return comparison
+}
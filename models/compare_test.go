@@ -0,0 +1,60 @@
+package models
+
+import (
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+// withCatAPI points cat_api_url at a local test server for the duration of
+// the test.
+func withCatAPI(t *testing.T, handler http.HandlerFunc) {
+    server := httptest.NewServer(handler)
+    originalURL, _ := web.AppConfig.String("cat_api_url")
+    web.AppConfig.Set("cat_api_url", server.URL)
+    t.Cleanup(func() {
+        web.AppConfig.Set("cat_api_url", originalURL)
+        server.Close()
+    })
+}
+
+func TestCompareBreeds(t *testing.T) {
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        switch {
+        case r.URL.Path == "/breeds/abys":
+            w.Write([]byte(`{"id":"abys","name":"Abyssinian","origin":"Egypt","life_span":"14 - 15","weight":{"metric":"3 - 5"},"energy_level":5,"grooming":1}`))
+        case r.URL.Path == "/breeds/bomb":
+            w.Write([]byte(`{"id":"bomb","name":"Bombay","origin":"United States","life_span":"12 - 16","weight":{"metric":"3 - 5"},"energy_level":3,"grooming":1}`))
+        case strings.HasPrefix(r.URL.Path, "/breeds/"):
+            w.Write([]byte(`{}`))
+        case r.URL.Path == "/images/search":
+            w.Write([]byte(`[{"url":"http://example.com/` + r.URL.Query().Get("breed_ids") + `.jpg"}]`))
+        }
+    })
+
+    comparison := CompareBreeds([]string{"abys", "nope", "bomb"})
+    assert.Equal(t, 2, len(comparison.Breeds))
+    assert.Equal(t, "abys", comparison.Breeds[0].ID)
+    assert.Equal(t, "bomb", comparison.Breeds[1].ID)
+    assert.Equal(t, Range{Min: 14, Max: 15}, comparison.Breeds[0].LifeSpan)
+    assert.Equal(t, Range{Min: 3, Max: 5}, comparison.Breeds[1].Weight)
+    assert.Equal(t, 5, comparison.Breeds[0].Traits["energy_level"])
+    assert.Equal(t, "http://example.com/bomb.jpg", comparison.Breeds[1].ImageURL)
+    assert.Contains(t, comparison.Errors["nope"], "breed not found")
+    assert.Equal(t, len(breedTraits), len(comparison.Traits))
+
+    // A concurrency below one still loads the breeds, one at a time.
+    web.AppConfig.Set("compare_concurrency", "0")
+    defer web.AppConfig.Set("compare_concurrency", "4")
+    comparison = CompareBreeds([]string{"abys", "bomb"})
+    assert.Equal(t, 2, len(comparison.Breeds))
+}
+
+func TestParseRange(t *testing.T) {
+    assert.Equal(t, Range{Min: 12, Max: 15}, parseRange("12 - 15"))
+    assert.Equal(t, Range{Min: 7, Max: 7}, parseRange("7"))
+    assert.Equal(t, Range{}, parseRange("unknown"))
+}
@@ -0,0 +1,276 @@
+package models
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "math"
+    "sort"
+    "sync"
+    "time"
+)
+
+const (
+    duelsFile = "duels.json"
+
+    initialRating = 1500.0
+    eloK          = 32.0
+
+    // Duels that are never answered are forgotten after this long.
+    duelTTL = 30 * time.Minute
+)
+
+// Duel is a pair of images shown side by side; the user picks the cuter one.
+type Duel struct {
+    ID        string    `json:"id"`
+    UserID    string    `json:"-"`
+    Left      Cat       `json:"left"`
+    Right     Cat       `json:"right"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+type DuelResult struct {
+    DuelID   string `json:"duel_id"`
+    WinnerID string `json:"winner_id"`
+}
+
+type Rating struct {
+    ID     string  `json:"id"`
+    Name   string  `json:"name,omitempty"`
+    URL    string  `json:"url,omitempty"`
+    Rating float64 `json:"rating"`
+    Wins   int     `json:"wins"`
+    Losses int     `json:"losses"`
+}
+
+type DuelRankings struct {
+    Images []Rating `json:"images"`
+    Breeds []Rating `json:"breeds"`
+}
+
+// duelsState is everything persisted in duelsFile.
+type duelsState struct {
+    Pending map[string]storedDuel `json:"pending"`
+    Images  map[string]*Rating    `json:"images"`
+    Breeds  map[string]*Rating    `json:"breeds"`
+}
+
+// storedDuel keeps the user a pending duel was dealt to, which Duel leaves
+// out of responses.
+type storedDuel struct {
+    Duel
+    UserID string `json:"user_id"`
+}
+
+var (
+    pendingDuels = make(map[string]Duel)
+    imageRatings = make(map[string]*Rating)
+    breedRatings = make(map[string]*Rating)
+    duelsLoaded  bool
+    duelMutex    sync.Mutex
+)
+
+// NewDuel picks two images from a fresh batch and remembers the pairing
+// until the user answers it.
+func NewDuel(userID string) (*Duel, error) {
+    cats, err := FetchCats()
+    if err != nil {
+        return nil, err
+    }
+    if len(cats) < 2 {
+        return nil, fmt.Errorf("not enough images for a duel")
+    }
+
+    id, err := newDuelID()
+    if err != nil {
+        return nil, err
+    }
+    duel := Duel{
+        ID:        id,
+        UserID:    userID,
+        Left:      cats[0],
+        Right:     cats[1],
+        CreatedAt: time.Now(),
+    }
+
+    duelMutex.Lock()
+    defer duelMutex.Unlock()
+
+    if err := loadDuels(); err != nil {
+        return nil, err
+    }
+    for key, pending := range pendingDuels {
+        if time.Since(pending.CreatedAt) > duelTTL {
+            delete(pendingDuels, key)
+        }
+    }
+    pendingDuels[duel.ID] = duel
+    if err := saveDuels(); err != nil {
+        delete(pendingDuels, duel.ID)
+        return nil, err
+    }
+    return &duel, nil
+}
+
+// RecordDuel applies the user's pick to the image and breed ratings. Each
+// duel can be answered once, by the user it was dealt to.
+func RecordDuel(userID string, result DuelResult) error {
+    duelMutex.Lock()
+    defer duelMutex.Unlock()
+
+    if err := loadDuels(); err != nil {
+        return err
+    }
+    duel, ok := pendingDuels[result.DuelID]
+    if !ok || duel.UserID != userID {
+        return fmt.Errorf("duel not found: %s", result.DuelID)
+    }
+
+    var winner, loser Cat
+    switch result.WinnerID {
+    case duel.Left.ID:
+        winner, loser = duel.Left, duel.Right
+    case duel.Right.ID:
+        winner, loser = duel.Right, duel.Left
+    default:
+        return fmt.Errorf("winner %s is not part of duel %s", result.WinnerID, result.DuelID)
+    }
+    undo := snapshotRatings()
+    delete(pendingDuels, result.DuelID)
+
+    updateElo(ratingFor(imageRatings, winner.ID, "", winner.URL), ratingFor(imageRatings, loser.ID, "", loser.URL))
+
+    // Every breed of the winner beats every different breed of the loser.
+    for _, wb := range winner.Breeds {
+        for _, lb := range loser.Breeds {
+            if wb.ID == lb.ID {
+                continue
+            }
+            updateElo(ratingFor(breedRatings, wb.ID, wb.Name, ""), ratingFor(breedRatings, lb.ID, lb.Name, ""))
+        }
+    }
+    if err := saveDuels(); err != nil {
+        undo()
+        pendingDuels[duel.ID] = duel
+        return err
+    }
+    return nil
+}
+
+// GetDuelRankings returns the top rated images and breeds.
+func GetDuelRankings(limit int) DuelRankings {
+    duelMutex.Lock()
+    defer duelMutex.Unlock()
+
+    if err := loadDuels(); err != nil {
+        logs.Error("Error loading duel ratings: %v", err)
+    }
+    return DuelRankings{
+        Images: sortedRatings(imageRatings, limit),
+        Breeds: sortedRatings(breedRatings, limit),
+    }
+}
+
+func ratingFor(ratings map[string]*Rating, id, name, url string) *Rating {
+    rating, ok := ratings[id]
+    if !ok {
+        rating = &Rating{ID: id, Name: name, URL: url, Rating: initialRating}
+        ratings[id] = rating
+    }
+    return rating
+}
+
+// updateElo moves both ratings by the standard Elo update: the winner gains
+// more the less likely the win was expected to be.
+func updateElo(winner, loser *Rating) {
+    expected := 1 / (1 + math.Pow(10, (loser.Rating-winner.Rating)/400))
+    delta := eloK * (1 - expected)
+
+    winner.Rating = math.Round((winner.Rating+delta)*10) / 10
+    loser.Rating = math.Round((loser.Rating-delta)*10) / 10
+    winner.Wins++
+    loser.Losses++
+}
+
+func sortedRatings(ratings map[string]*Rating, limit int) []Rating {
+    sorted := make([]Rating, 0, len(ratings))
+    for _, rating := range ratings {
+        sorted = append(sorted, *rating)
+    }
+    sort.Slice(sorted, func(i, j int) bool {
+        if sorted[i].Rating != sorted[j].Rating {
+            return sorted[i].Rating > sorted[j].Rating
+        }
+        return sorted[i].ID < sorted[j].ID
+    })
+    if limit > 0 && len(sorted) > limit {
+        sorted = sorted[:limit]
+    }
+    return sorted
+}
+
+// snapshotRatings returns a function that puts the ratings back as they
+// are now. Callers must hold duelMutex.
+func snapshotRatings() func() {
+    images, breeds := copyRatings(imageRatings), copyRatings(breedRatings)
+    return func() {
+        imageRatings, breedRatings = images, breeds
+    }
+}
+
+func copyRatings(ratings map[string]*Rating) map[string]*Rating {
+    copied := make(map[string]*Rating, len(ratings))
+    for id, rating := range ratings {
+        r := *rating
+        copied[id] = &r
+    }
+    return copied
+}
+
+// loadDuels reads the ratings and pending duels on first use. Callers must
+// hold duelMutex.
+func loadDuels() error {
+    if duelsLoaded {
+        return nil
+    }
+    var state duelsState
+    if err := loadJSON(duelsFile, &state); err != nil {
+        return err
+    }
+    for id, stored := range state.Pending {
+        duel := stored.Duel
+        duel.UserID = stored.UserID
+        pendingDuels[id] = duel
+    }
+    for id, rating := range state.Images {
+        imageRatings[id] = rating
+    }
+    for id, rating := range state.Breeds {
+        breedRatings[id] = rating
+    }
+    duelsLoaded = true
+    return nil
+}
+
+// saveDuels writes the ratings and pending duels. Callers must hold
+// duelMutex.
+func saveDuels() error {
+    state := duelsState{
+        Pending: make(map[string]storedDuel, len(pendingDuels)),
+        Images:  imageRatings,
+        Breeds:  breedRatings,
+    }
+    for id, duel := range pendingDuels {
+        state.Pending[id] = storedDuel{Duel: duel, UserID: duel.UserID}
+    }
+    return saveJSON(duelsFile, state)
+}
+
+func newDuelID() (string, error) {
+    buf := make([]byte, 8)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(buf), nil
+}
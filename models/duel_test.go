@@ -0,0 +1,86 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+)
+
+func resetDuels() {
+    duelMutex.Lock()
+    pendingDuels = make(map[string]Duel)
+    imageRatings = make(map[string]*Rating)
+    breedRatings = make(map[string]*Rating)
+    duelsLoaded = true
+    duelMutex.Unlock()
+}
+
+func TestDuelFlow(t *testing.T) {
+    resetDuels()
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`[
+            {"id":"img1","url":"http://example.com/1.jpg","breeds":[{"id":"abys","name":"Abyssinian"}]},
+            {"id":"img2","url":"http://example.com/2.jpg","breeds":[{"id":"beng","name":"Bengal"}]}
+        ]`))
+    })
+
+    duel, err := NewDuel("alice")
+    assert.NoError(t, err)
+    assert.Equal(t, "img1", duel.Left.ID)
+    assert.Equal(t, "img2", duel.Right.ID)
+
+    // Only the user the duel was dealt to can answer it, and only with one
+    // of its images.
+    assert.Error(t, RecordDuel("bob", DuelResult{DuelID: duel.ID, WinnerID: "img1"}))
+    assert.Error(t, RecordDuel("alice", DuelResult{DuelID: duel.ID, WinnerID: "img9"}))
+
+    assert.NoError(t, RecordDuel("alice", DuelResult{DuelID: duel.ID, WinnerID: "img2"}))
+    assert.Error(t, RecordDuel("alice", DuelResult{DuelID: duel.ID, WinnerID: "img2"}))
+
+    rankings := GetDuelRankings(10)
+    assert.Equal(t, 2, len(rankings.Images))
+    assert.Equal(t, "img2", rankings.Images[0].ID)
+    assert.Equal(t, 1516.0, rankings.Images[0].Rating)
+    assert.Equal(t, 1484.0, rankings.Images[1].Rating)
+    assert.Equal(t, "beng", rankings.Breeds[0].ID)
+    assert.Equal(t, "Bengal", rankings.Breeds[0].Name)
+    assert.Equal(t, 1, rankings.Breeds[1].Losses)
+}
+
+func TestUpdateElo(t *testing.T) {
+    favourite := &Rating{ID: "a", Rating: 1700}
+    underdog := &Rating{ID: "b", Rating: 1300}
+
+    updateElo(underdog, favourite)
+    assert.True(t, underdog.Rating-1300 > 16, "an upset moves ratings more than an even match")
+    assert.Equal(t, 3000.0, underdog.Rating+favourite.Rating)
+}
+
+func TestDuelsSurviveRestart(t *testing.T) {
+    withDataDir(t)
+    resetDuels()
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`[
+            {"id":"img1","url":"http://example.com/1.jpg","breeds":[{"id":"abys","name":"Abyssinian"}]},
+            {"id":"img2","url":"http://example.com/2.jpg","breeds":[{"id":"beng","name":"Bengal"}]}
+        ]`))
+    })
+    restart := func() {
+        resetDuels()
+        duelMutex.Lock()
+        duelsLoaded = false
+        duelMutex.Unlock()
+    }
+
+    duel, err := NewDuel("alice")
+    assert.NoError(t, err)
+    restart()
+    assert.Error(t, RecordDuel("bob", DuelResult{DuelID: duel.ID, WinnerID: "img1"}), "the duel still belongs to alice")
+    assert.NoError(t, RecordDuel("alice", DuelResult{DuelID: duel.ID, WinnerID: "img1"}))
+
+    restart()
+    rankings := GetDuelRankings(10)
+    assert.Equal(t, "img1", rankings.Images[0].ID)
+    assert.Equal(t, 1516.0, rankings.Images[0].Rating)
+    assert.Equal(t, "abys", rankings.Breeds[0].ID)
+}
@@ -0,0 +1,327 @@
+package models
+
+import (
+    "archive/zip"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/server/web"
+    "io"
+    "mime"
+    "net/http"
+    "net/url"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    ExportPending = "pending"
+    ExportRunning = "running"
+    ExportDone    = "done"
+    ExportFailed  = "failed"
+
+    exportsDir = "exports"
+    // exportJobsFile keeps the job table, so finished exports can still be
+    // downloaded and purged after a restart.
+    exportJobsFile = "exports.json"
+
+    // maxExportImageSize caps each downloaded image so one huge file can't
+    // fill the disk.
+    maxExportImageSize = 20 << 20
+)
+
+// ExportJob is a ZIP export being built in the background.
+type ExportJob struct {
+    ID         string    `json:"id"`
+    Status     string    `json:"status"`
+    Count      int       `json:"count"`
+    Missing    int       `json:"missing"`
+    Error      string    `json:"error,omitempty"`
+    CreatedAt  time.Time `json:"created_at"`
+    FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// exportManifestEntry describes one favorite in a ZIP export. File is the
+// image's path inside the archive, empty when it couldn't be downloaded.
+type exportManifestEntry struct {
+    FavoriteRow
+    File  string `json:"file,omitempty"`
+    Error string `json:"error,omitempty"`
+}
+
+var (
+    exportJobs       = make(map[string]*ExportJob)
+    exportJobsLoaded bool
+    exportMutex      sync.Mutex
+    exportClient = newImageClient(30 * time.Second)
+)
+
+// StartFavoritesZipExport snapshots the favorites and builds a ZIP of their
+// images plus a manifest.json in the background. Poll the returned job
+// until it is done, then download it with ExportJobFile.
+func StartFavoritesZipExport() (*ExportJob, error) {
+    buf := make([]byte, 16)
+    if _, err := rand.Read(buf); err != nil {
+        return nil, err
+    }
+    job := &ExportJob{ID: hex.EncodeToString(buf), Status: ExportPending, CreatedAt: time.Now()}
+    rows, err := ExportFavorites()
+    if err != nil {
+        return nil, err
+    }
+
+    exportMutex.Lock()
+    if err := loadExportJobs(); err != nil {
+        exportMutex.Unlock()
+        return nil, err
+    }
+    exportJobs[job.ID] = job
+    if err := saveJSON(exportJobsFile, exportJobs); err != nil {
+        delete(exportJobs, job.ID)
+        exportMutex.Unlock()
+        return nil, err
+    }
+    snapshot := *job
+    exportMutex.Unlock()
+
+    go runExportJob(job.ID, rows)
+    return &snapshot, nil
+}
+
+// GetExportJob returns the state of an export job.
+func GetExportJob(id string) (*ExportJob, error) {
+    exportMutex.Lock()
+    defer exportMutex.Unlock()
+
+    if err := loadExportJobs(); err != nil {
+        return nil, err
+    }
+    job, ok := exportJobs[id]
+    if !ok {
+        return nil, fmt.Errorf("export not found: %s", id)
+    }
+    snapshot := *job
+    return &snapshot, nil
+}
+
+// ExportJobFile returns the path of a finished export's ZIP file.
+func ExportJobFile(id string) (string, error) {
+    job, err := GetExportJob(id)
+    if err != nil {
+        return "", err
+    }
+    if job.Status != ExportDone {
+        return "", fmt.Errorf("export is not ready: %s", job.Status)
+    }
+    return exportPath(id), nil
+}
+
+// PurgeExportJobs deletes exports that finished longer than
+// favorites_export_retention before now. It returns how many were deleted.
+func PurgeExportJobs(now time.Time) (int, error) {
+    retention, err := time.ParseDuration(web.AppConfig.DefaultString("favorites_export_retention", "24h"))
+    if err != nil || retention <= 0 {
+        retention = 24 * time.Hour
+    }
+
+    exportMutex.Lock()
+    defer exportMutex.Unlock()
+
+    if err := loadExportJobs(); err != nil {
+        return 0, err
+    }
+    purged := 0
+    var lastErr error
+    for id, job := range exportJobs {
+        if job.FinishedAt.IsZero() || now.Sub(job.FinishedAt) < retention {
+            continue
+        }
+        if err := os.Remove(exportPath(id)); err != nil && !os.IsNotExist(err) {
+            lastErr = err
+            continue
+        }
+        delete(exportJobs, id)
+        purged++
+    }
+    if purged > 0 {
+        if err := saveJSON(exportJobsFile, exportJobs); err != nil {
+            lastErr = err
+        }
+    }
+    return purged, lastErr
+}
+
+func runExportJob(id string, rows []FavoriteRow) {
+    updateExportJob(id, func(job *ExportJob) { job.Status = ExportRunning })
+
+    missing, err := writeFavoritesZip(exportPath(id), rows)
+    updateExportJob(id, func(job *ExportJob) {
+        job.FinishedAt = time.Now()
+        if err != nil {
+            job.Status = ExportFailed
+            job.Error = err.Error()
+            return
+        }
+        job.Status = ExportDone
+        job.Count = len(rows)
+        job.Missing = missing
+    })
+}
+
+func updateExportJob(id string, update func(job *ExportJob)) {
+    exportMutex.Lock()
+    defer exportMutex.Unlock()
+
+    if job, ok := exportJobs[id]; ok {
+        update(job)
+        if err := saveJSON(exportJobsFile, exportJobs); err != nil {
+            logs.Error("Error saving export jobs: %v", err)
+        }
+    }
+}
+
+// loadExportJobs reads the job table on first use. Jobs that were still
+// running when the server stopped are marked failed. Callers must hold
+// exportMutex.
+func loadExportJobs() error {
+    if exportJobsLoaded {
+        return nil
+    }
+    stored := make(map[string]*ExportJob)
+    if err := loadJSON(exportJobsFile, &stored); err != nil {
+        return err
+    }
+    interrupted := false
+    for id, job := range stored {
+        if job.Status == ExportPending || job.Status == ExportRunning {
+            job.Status = ExportFailed
+            job.Error = "interrupted by a restart"
+            job.FinishedAt = time.Now()
+            os.Remove(exportPath(id) + ".tmp")
+            interrupted = true
+        }
+        exportJobs[id] = job
+    }
+    if interrupted {
+        if err := saveJSON(exportJobsFile, exportJobs); err != nil {
+            return err
+        }
+    }
+    exportJobsLoaded = true
+    return nil
+}
+
+// writeFavoritesZip downloads each favorite's image into the archive at
+// target. Images that can't be downloaded are listed in the manifest with
+// the error instead of failing the export; it returns how many there were.
+func writeFavoritesZip(target string, rows []FavoriteRow) (int, error) {
+    if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+        return 0, err
+    }
+    tmp := target + ".tmp"
+    file, err := os.Create(tmp)
+    if err != nil {
+        return 0, err
+    }
+    defer os.Remove(tmp)
+
+    archive := zip.NewWriter(file)
+    manifest := make([]exportManifestEntry, 0, len(rows))
+    missing := 0
+    for _, row := range rows {
+        entry := exportManifestEntry{FavoriteRow: row}
+        name, err := addExportImage(archive, row)
+        if err != nil {
+            entry.Error = err.Error()
+            missing++
+        } else {
+            entry.File = name
+        }
+        manifest = append(manifest, entry)
+    }
+
+    w, err := archive.Create("manifest.json")
+    if err == nil {
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        err = encoder.Encode(manifest)
+    }
+    if err == nil {
+        err = archive.Close()
+    }
+    if closeErr := file.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return 0, err
+    }
+    return missing, os.Rename(tmp, target)
+}
+
+func addExportImage(archive *zip.Writer, row FavoriteRow) (string, error) {
+    target, err := checkImageURL(row.URL)
+    if err != nil {
+        return "", err
+    }
+    resp, err := exportClient.Get(target.String())
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("image responded with status %d", resp.StatusCode)
+    }
+
+    // Read the whole image first so a failed download never leaves a
+    // truncated file in the archive.
+    data, err := io.ReadAll(io.LimitReader(resp.Body, maxExportImageSize+1))
+    if err != nil {
+        return "", err
+    }
+    if len(data) > maxExportImageSize {
+        return "", fmt.Errorf("image is larger than %d bytes", maxExportImageSize)
+    }
+
+    name := "images/" + exportFileName(row.ID) + imageExtension(row.URL, resp.Header.Get("Content-Type"))
+    w, err := archive.Create(name)
+    if err != nil {
+        return "", err
+    }
+    _, err = w.Write(data)
+    return name, err
+}
+
+// imageExtension prefers the extension in the URL and falls back to the
+// response's content type.
+func imageExtension(imageURL string, contentType string) string {
+    if u, err := url.Parse(imageURL); err == nil {
+        if ext := strings.ToLower(path.Ext(u.Path)); ext != "" && len(ext) <= 5 {
+            return ext
+        }
+    }
+    if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+        return exts[0]
+    }
+    return ""
+}
+
+// exportFileName keeps image IDs from escaping the images folder.
+func exportFileName(id string) string {
+    return strings.Map(func(r rune) rune {
+        if r == '/' || r == '\\' || r == '.' {
+            return '_'
+        }
+        return r
+    }, id)
+}
+
+func exportPath(id string) string {
+    return filepath.Join(dataDir(), exportsDir, id+".zip")
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "mime"
+    "path"
+    "sort"
+    "strings"
+    "time"
+)
+
+const (
+    // backfillRetryDelay is the wait after the first failed backfill
+    // lookup; it doubles with every further failure.
+    backfillRetryDelay = time.Hour
+    // maxBackfillFailures is how often a lookup may fail before the
+    // favorite is no longer backfilled.
+    maxBackfillFailures = 5
+)
+
+// BreedCount is how many favorites show a breed.
+type BreedCount struct {
+    ID    string `json:"id"`
+    Name  string `json:"name"`
+    Count int    `json:"count"`
+}
+
+// BreedDistribution summarises the breeds across all favorites. An image
+// with several breeds counts towards each of them; Unknown counts images
+// without breed information.
+type BreedDistribution struct {
+    Total   int          `json:"total"`
+    Unknown int          `json:"unknown"`
+    Breeds  []BreedCount `json:"breeds"`
+}
+
+// BackfillFavoriteImages looks up the image record of up to limit
+// favorites that were saved without one. It returns how many were
+// backfilled. Failed lookups are retried with a growing delay and given up
+// after maxBackfillFailures, so they don't hold up the others.
+func BackfillFavoriteImages(limit int) (int, error) {
+    favMutex.Lock()
+    if err := loadFavorites(); err != nil {
+        favMutex.Unlock()
+        return 0, err
+    }
+    now := time.Now()
+    missing := []string{}
+    for _, fav := range favorites {
+        if fav.needsBackfill(now) && len(missing) < limit {
+            missing = append(missing, fav.ID)
+        }
+    }
+    favMutex.Unlock()
+
+    images := make(map[string]*Cat, len(missing))
+    failed := make(map[string]bool)
+    var lastErr error
+    for _, id := range missing {
+        cat, err := FetchImage(id)
+        if err != nil {
+            failed[id] = true
+            lastErr = err
+            continue
+        }
+        images[id] = withMimeType(*cat)
+    }
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    backfilled := 0
+    for i := range favorites {
+        if favorites[i].Image != nil {
+            continue
+        }
+        if image, ok := images[favorites[i].ID]; ok {
+            favorites[i].Image = image
+            favorites[i].BackfillFailures = 0
+            favorites[i].BackfillRetryAt = nil
+            backfilled++
+        } else if failed[favorites[i].ID] {
+            favorites[i].BackfillFailures++
+            retryAt := now.Add(backfillRetryDelay << (favorites[i].BackfillFailures - 1))
+            favorites[i].BackfillRetryAt = &retryAt
+        }
+    }
+    if backfilled == 0 && len(failed) == 0 {
+        return 0, nil
+    }
+    if err := saveFavorites(); err != nil {
+        return backfilled, err
+    }
+    if backfilled == 0 && lastErr != nil {
+        return 0, fmt.Errorf("error backfilling favorite images: %v", lastErr)
+    }
+    return backfilled, nil
+}
+
+// needsBackfill reports whether the favorite's image record should be
+// looked up now.
+func (f FavoriteImage) needsBackfill(now time.Time) bool {
+    if f.Image != nil || f.BackfillFailures >= maxBackfillFailures {
+        return false
+    }
+    return f.BackfillRetryAt == nil || !now.Before(*f.BackfillRetryAt)
+}
+
+// GetFavoriteBreedDistribution counts favorites per breed, most common
+// first.
+func GetFavoriteBreedDistribution() BreedDistribution {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        logs.Error("Error loading favorites: %v", err)
+    }
+
+    distribution := BreedDistribution{Total: len(favorites), Breeds: []BreedCount{}}
+    counts := make(map[string]*BreedCount)
+    for _, fav := range favorites {
+        if fav.Image == nil || len(fav.Image.Breeds) == 0 {
+            distribution.Unknown++
+            continue
+        }
+        for _, breed := range fav.Image.Breeds {
+            count, ok := counts[breed.ID]
+            if !ok {
+                count = &BreedCount{ID: breed.ID, Name: breed.Name}
+                counts[breed.ID] = count
+            }
+            count.Count++
+        }
+    }
+
+    for _, count := range counts {
+        distribution.Breeds = append(distribution.Breeds, *count)
+    }
+    sort.Slice(distribution.Breeds, func(i, j int) bool {
+        if distribution.Breeds[i].Count != distribution.Breeds[j].Count {
+            return distribution.Breeds[i].Count > distribution.Breeds[j].Count
+        }
+        return distribution.Breeds[i].Name < distribution.Breeds[j].Name
+    })
+    return distribution
+}
+
+// hasBreed reports whether the favorite's image shows the breed.
+func (f FavoriteImage) hasBreed(breedID string) bool {
+    if f.Image == nil {
+        return false
+    }
+    for _, breed := range f.Image.Breeds {
+        if strings.EqualFold(breed.ID, breedID) {
+            return true
+        }
+    }
+    return false
+}
+
+// cachedImage returns the record of a recently served image for storing
+// with a favorite, or nil when it has to be backfilled.
+func cachedImage(id string) *Cat {
+    cat, ok := LookupCat(id)
+    if !ok {
+        return nil
+    }
+    return withMimeType(cat)
+}
+
+// withMimeType fills in the MIME type from the image URL when TheCatAPI
+// didn't send one.
+func withMimeType(cat Cat) *Cat {
+    if cat.MimeType == "" {
+        cat.MimeType = mime.TypeByExtension(strings.ToLower(path.Ext(cat.URL)))
+    }
+    return &cat
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestSaveFavoriteKeepsImageRecord(t *testing.T) {
+    resetCollections()
+    rememberCats([]Cat{{
+        ID:     "img1",
+        URL:    "http://example.com/img1.JPG",
+        Width:  800,
+        Height: 600,
+        Breeds: []Breed{{ID: "beng", Name: "Bengal"}},
+    }})
+
+    assert.NoError(t, SaveFavorite("img1", "http://example.com/img1.JPG"))
+    fav := GetFavorites()[0]
+    assert.Equal(t, 800, fav.Image.Width)
+    assert.Equal(t, "image/jpeg", fav.Image.MimeType)
+    assert.Equal(t, "Bengal", fav.Image.Breeds[0].Name)
+}
+
+func TestBackfillFavoriteImages(t *testing.T) {
+    resetCollections()
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/images/old1":
+            w.Write([]byte(`{"id":"old1","url":"http://example.com/old1.png","width":10,"height":20,"breeds":[{"id":"abys","name":"Abyssinian"}]}`))
+        case "/images/old2":
+            w.Write([]byte(`{"id":"old2","url":"http://example.com/old2.jpg","breeds":[{"id":"abys","name":"Abyssinian"},{"id":"beng","name":"Bengal"}]}`))
+        default:
+            http.NotFound(w, r)
+        }
+    })
+    favMutex.Lock()
+    favorites = []FavoriteImage{
+        {ID: "old1", URL: "http://example.com/old1.png"},
+        {ID: "old2", URL: "http://example.com/old2.jpg"},
+        {ID: "gone", URL: "http://example.com/gone.jpg"},
+    }
+    favMutex.Unlock()
+
+    backfilled, err := BackfillFavoriteImages(10)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, backfilled)
+
+    favs := GetFavorites()
+    assert.Equal(t, "image/png", favs[0].Image.MimeType)
+    assert.Equal(t, 20, favs[0].Image.Height)
+    assert.Nil(t, favs[2].Image)
+    assert.Equal(t, 1, favs[2].BackfillFailures)
+
+    abys, _ := QueryFavorites(FavoriteQuery{Breed: "ABYS"})
+    assert.Equal(t, []string{"old1", "old2"}, favoriteIDs(abys))
+    beng, _ := QueryFavorites(FavoriteQuery{Breed: "beng"})
+    assert.Equal(t, []string{"old2"}, favoriteIDs(beng))
+
+    distribution := GetFavoriteBreedDistribution()
+    assert.Equal(t, 3, distribution.Total)
+    assert.Equal(t, 1, distribution.Unknown)
+    assert.Equal(t, []BreedCount{
+        {ID: "abys", Name: "Abyssinian", Count: 2},
+        {ID: "beng", Name: "Bengal", Count: 1},
+    }, distribution.Breeds)
+}
+
+func TestBackfillBacksOffFailedImages(t *testing.T) {
+    resetCollections()
+    requests := map[string]int{}
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        requests[r.URL.Path]++
+        if r.URL.Path == "/images/new1" {
+            w.Write([]byte(`{"id":"new1","url":"http://example.com/new1.jpg"}`))
+            return
+        }
+        http.NotFound(w, r)
+    })
+    favMutex.Lock()
+    favorites = []FavoriteImage{
+        {ID: "gone", URL: "http://example.com/gone.jpg"},
+        {ID: "new1", URL: "http://example.com/new1.jpg"},
+    }
+    favMutex.Unlock()
+
+    _, err := BackfillFavoriteImages(1)
+    assert.Error(t, err)
+    backfilled, err := BackfillFavoriteImages(1)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, backfilled, "a failed image doesn't take the place of the others")
+    assert.Equal(t, 1, requests["/images/gone"])
+
+    favMutex.Lock()
+    past := time.Now().Add(-time.Minute)
+    favorites[0].BackfillRetryAt = &past
+    favorites[0].BackfillFailures = maxBackfillFailures - 1
+    favMutex.Unlock()
+    _, err = BackfillFavoriteImages(1)
+    assert.Error(t, err)
+    assert.Equal(t, 2, requests["/images/gone"])
+
+    favMutex.Lock()
+    favorites[0].BackfillRetryAt = &past
+    favMutex.Unlock()
+    backfilled, err = BackfillFavoriteImages(1)
+    assert.NoError(t, err)
+    assert.Equal(t, 0, backfilled)
+    assert.Equal(t, 2, requests["/images/gone"], "lookups are given up after maxBackfillFailures")
+}
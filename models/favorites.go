@@ -0,0 +1,450 @@
+package models
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "sort"
+    "strings"
+    "time"
+)
+
+const (
+    favoritesFile = "favorites.json"
+
+    maxFavoriteTags = 20
+    maxTagLength    = 32
+    maxNotesLength  = 1000
+
+    maxFavoritePageSize = 100
+
+    FavoriteSortManual = "manual"
+    FavoriteSortAdded  = "added"
+    FavoriteSortBreed  = "breed"
+    FavoriteSortVotes  = "votes"
+)
+
+// FavoriteQuery filters and orders the favorites list. Tag matches one tag
+// exactly; Q matches notes, tags and the image ID case-insensitively; Breed
+// matches a breed ID of the image. Sort is one of the FavoriteSort values
+// and defaults to the manual order. Cursor and Limit select a page.
+type FavoriteQuery struct {
+    Collection string
+    Tag        string
+    Q          string
+    Breed      string
+    Sort       string
+    Cursor     string
+    Limit      int
+}
+
+// FavoritePage is one page of favorites. NextCursor is empty on the last
+// page.
+type FavoritePage struct {
+    Items      []FavoriteImage `json:"items"`
+    NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// favoritesState is everything persisted in favoritesFile. Collections and
+// the trash live alongside the favorites so a favorite is never lost or
+// duplicated between them.
+type favoritesState struct {
+    Favorites     []FavoriteImage   `json:"favorites"`
+    Collections   []Collection      `json:"collections"`
+    CollectionSeq int               `json:"collection_seq"`
+    Trash         []TrashedFavorite `json:"trash"`
+}
+
+var favoritesLoaded bool
+
+// FavoriteUpdate changes a favorite's notes and tags. Fields left nil are
+// kept as they are.
+type FavoriteUpdate struct {
+    Notes *string   `json:"notes"`
+    Tags  *[]string `json:"tags"`
+}
+
+type TagCount struct {
+    Tag   string `json:"tag"`
+    Count int    `json:"count"`
+}
+
+// favoriteSortKey is what a favorite is ordered by. Favorites with the
+// same key are ordered by ID so a page cursor can always tell where it
+// left off.
+type favoriteSortKey struct {
+    ID    string    `json:"id"`
+    Added time.Time `json:"added"`
+    Breed string    `json:"breed,omitempty"`
+    Score int       `json:"score,omitempty"`
+}
+
+// favoriteCursor is the decoded next_cursor of a page: the sort, the key
+// of the page's last favorite and its position in the results.
+type favoriteCursor struct {
+    Sort string          `json:"sort"`
+    Key  favoriteSortKey `json:"key"`
+    Pos  int             `json:"pos"`
+}
+
+// QueryFavorites returns the favorites matching the query in the requested
+// order. Cursor and Limit are ignored; see PageFavorites.
+func QueryFavorites(query FavoriteQuery) ([]FavoriteImage, error) {
+    result, _, err := queryFavorites(query)
+    return result, err
+}
+
+// queryFavorites is QueryFavorites that also returns the vote scores it
+// sorted by, if any.
+func queryFavorites(query FavoriteQuery) ([]FavoriteImage, map[string]int, error) {
+    var scores map[string]int
+    switch query.Sort {
+    case "", FavoriteSortManual, FavoriteSortAdded, FavoriteSortBreed:
+    case FavoriteSortVotes:
+        // Read the ledger before taking favMutex; it has its own lock.
+        scores = make(map[string]int)
+        for _, vote := range GetVotes("") {
+            scores[vote.ImageID] += voteScore[vote.Value]
+        }
+    default:
+        return nil, nil, fmt.Errorf("unknown sort: %q", query.Sort)
+    }
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, nil, err
+    }
+
+    if query.Collection != "" && query.Collection != DefaultCollection {
+        if _, err := findCollection(query.Collection); err != nil {
+            return nil, nil, err
+        }
+    }
+    tag := normalizeTag(query.Tag)
+    q := strings.ToLower(strings.TrimSpace(query.Q))
+
+    result := []FavoriteImage{}
+    for _, fav := range favorites {
+        if query.Collection != "" && collectionOf(fav) != query.Collection {
+            continue
+        }
+        if tag != "" && !containsString(fav.Tags, tag) {
+            continue
+        }
+        if q != "" && !fav.matches(q) {
+            continue
+        }
+        if query.Breed != "" && !fav.hasBreed(query.Breed) {
+            continue
+        }
+        result = append(result, fav)
+    }
+
+    if !isManualSort(query.Sort) {
+        sort.SliceStable(result, func(i, j int) bool {
+            return sortsBefore(query.Sort, result[i].sortKey(scores), result[j].sortKey(scores))
+        })
+    }
+    return result, scores, nil
+}
+
+// PageFavorites returns one page of QueryFavorites. The cursor is opaque to
+// clients: it is the next_cursor of the previous page. A page continues
+// after the last favorite of the previous one even when that favorite has
+// since been removed or has moved.
+func PageFavorites(query FavoriteQuery) (*FavoritePage, error) {
+    limit := query.Limit
+    if limit <= 0 || limit > maxFavoritePageSize {
+        limit = maxFavoritePageSize
+    }
+
+    result, scores, err := queryFavorites(query)
+    if err != nil {
+        return nil, err
+    }
+
+    start := 0
+    if query.Cursor != "" {
+        cursor, err := decodeFavoriteCursor(query.Cursor)
+        if err != nil || isManualSort(cursor.Sort) != isManualSort(query.Sort) ||
+            (!isManualSort(query.Sort) && cursor.Sort != query.Sort) {
+            return nil, fmt.Errorf("invalid cursor")
+        }
+        start = resumeFavoritePage(query.Sort, result, scores, cursor)
+    }
+
+    page := &FavoritePage{Items: result[start:]}
+    if len(page.Items) > limit {
+        page.Items = page.Items[:limit]
+        last := start + limit - 1
+        page.NextCursor = encodeFavoriteCursor(favoriteCursor{
+            Sort: query.Sort,
+            Key:  result[last].sortKey(scores),
+            Pos:  last,
+        })
+    }
+    return page, nil
+}
+
+// resumeFavoritePage returns the index of the first favorite after the
+// cursor. In the manual order that is the one after the cursor's favorite,
+// or the one now at its position if it was removed. Other orders continue
+// with the first favorite whose key sorts after the cursor's.
+func resumeFavoritePage(sortBy string, result []FavoriteImage, scores map[string]int, cursor favoriteCursor) int {
+    if isManualSort(sortBy) {
+        for i, fav := range result {
+            if fav.ID == cursor.Key.ID {
+                return i + 1
+            }
+        }
+        if cursor.Pos < 0 {
+            return 0
+        }
+        if cursor.Pos > len(result) {
+            return len(result)
+        }
+        return cursor.Pos
+    }
+    return sort.Search(len(result), func(i int) bool {
+        return sortsBefore(sortBy, cursor.Key, result[i].sortKey(scores))
+    })
+}
+
+func encodeFavoriteCursor(cursor favoriteCursor) string {
+    data, _ := json.Marshal(cursor)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFavoriteCursor(s string) (favoriteCursor, error) {
+    var cursor favoriteCursor
+    data, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil {
+        return cursor, err
+    }
+    if err := json.Unmarshal(data, &cursor); err != nil {
+        return cursor, err
+    }
+    if cursor.Key.ID == "" {
+        return cursor, fmt.Errorf("cursor without a favorite")
+    }
+    return cursor, nil
+}
+
+func isManualSort(sortBy string) bool {
+    return sortBy == "" || sortBy == FavoriteSortManual
+}
+
+func (f FavoriteImage) sortKey(scores map[string]int) favoriteSortKey {
+    return favoriteSortKey{
+        ID:    f.ID,
+        Added: f.AddedAt,
+        Breed: strings.ToLower(f.breedName()),
+        Score: scores[f.ID],
+    }
+}
+
+// sortsBefore reports whether a comes before b in the sort. Favorites
+// without a known breed go last in the breed order.
+func sortsBefore(sortBy string, a, b favoriteSortKey) bool {
+    switch sortBy {
+    case FavoriteSortAdded:
+        if !a.Added.Equal(b.Added) {
+            return a.Added.After(b.Added)
+        }
+    case FavoriteSortBreed:
+        if a.Breed != b.Breed {
+            if a.Breed == "" || b.Breed == "" {
+                return b.Breed == ""
+            }
+            return a.Breed < b.Breed
+        }
+    case FavoriteSortVotes:
+        if a.Score != b.Score {
+            return a.Score > b.Score
+        }
+    }
+    return a.ID < b.ID
+}
+
+// ReorderFavorites sets the manual order. The listed favorites move to the
+// front in the given order; the rest keep their relative order after them,
+// so a client can reorder just the part of the grid it has loaded.
+func ReorderFavorites(ids []string) error {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return err
+    }
+
+    byID := make(map[string]FavoriteImage, len(favorites))
+    for _, fav := range favorites {
+        byID[fav.ID] = fav
+    }
+
+    ordered := make([]FavoriteImage, 0, len(favorites))
+    seen := make(map[string]bool, len(ids))
+    for _, id := range ids {
+        fav, ok := byID[id]
+        if !ok {
+            return fmt.Errorf("favorite not found with ID: %s", id)
+        }
+        if seen[id] {
+            return fmt.Errorf("favorite listed twice: %s", id)
+        }
+        seen[id] = true
+        ordered = append(ordered, fav)
+    }
+    for _, fav := range favorites {
+        if !seen[fav.ID] {
+            ordered = append(ordered, fav)
+        }
+    }
+
+    previous := favorites
+    favorites = ordered
+    if err := saveFavorites(); err != nil {
+        favorites = previous
+        return err
+    }
+    return nil
+}
+
+// UpdateFavorite edits a favorite's notes and tags. Tags are lower-cased
+// and de-duplicated.
+func UpdateFavorite(id string, update FavoriteUpdate) (*FavoriteImage, error) {
+    var tags []string
+    if update.Tags != nil {
+        var err error
+        if tags, err = normalizeTags(*update.Tags); err != nil {
+            return nil, err
+        }
+    }
+    if update.Notes != nil && len(*update.Notes) > maxNotesLength {
+        return nil, fmt.Errorf("notes must be at most %d characters", maxNotesLength)
+    }
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    for i := range favorites {
+        if favorites[i].ID != id {
+            continue
+        }
+        if update.Notes != nil {
+            favorites[i].Notes = strings.TrimSpace(*update.Notes)
+        }
+        if update.Tags != nil {
+            favorites[i].Tags = tags
+        }
+        fav := favorites[i]
+        return &fav, saveFavorites()
+    }
+    return nil, fmt.Errorf("favorite not found with ID: %s", id)
+}
+
+// GetFavoriteTags lists every tag in use with the number of favorites
+// carrying it, most used first.
+func GetFavoriteTags() []TagCount {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        logs.Error("Error loading favorites: %v", err)
+    }
+
+    counts := make(map[string]int)
+    for _, fav := range favorites {
+        for _, tag := range fav.Tags {
+            counts[tag]++
+        }
+    }
+
+    result := make([]TagCount, 0, len(counts))
+    for tag, count := range counts {
+        result = append(result, TagCount{Tag: tag, Count: count})
+    }
+    sort.Slice(result, func(i, j int) bool {
+        if result[i].Count != result[j].Count {
+            return result[i].Count > result[j].Count
+        }
+        return result[i].Tag < result[j].Tag
+    })
+    return result
+}
+
+func (f FavoriteImage) matches(q string) bool {
+    if strings.Contains(strings.ToLower(f.Notes), q) || strings.Contains(strings.ToLower(f.ID), q) {
+        return true
+    }
+    for _, tag := range f.Tags {
+        if strings.Contains(tag, q) {
+            return true
+        }
+    }
+    return false
+}
+
+func (f FavoriteImage) breedName() string {
+    if f.Image == nil || len(f.Image.Breeds) == 0 {
+        return ""
+    }
+    return f.Image.Breeds[0].Name
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+    result := []string{}
+    for _, tag := range tags {
+        tag = normalizeTag(tag)
+        if tag == "" || containsString(result, tag) {
+            continue
+        }
+        if len(tag) > maxTagLength {
+            return nil, fmt.Errorf("tags must be at most %d characters: %q", maxTagLength, tag)
+        }
+        result = append(result, tag)
+    }
+    if len(result) > maxFavoriteTags {
+        return nil, fmt.Errorf("a favorite can have at most %d tags", maxFavoriteTags)
+    }
+    return result, nil
+}
+
+func normalizeTag(tag string) string {
+    return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// loadFavorites reads the persisted favorites, collections and trash on
+// first use.
+// Callers must hold favMutex.
+func loadFavorites() error {
+    if favoritesLoaded {
+        return nil
+    }
+    state := favoritesState{Favorites: favorites, Collections: collections, CollectionSeq: collectionSeq, Trash: favoriteTrash}
+    if err := loadJSON(favoritesFile, &state); err != nil {
+        return err
+    }
+    favorites, collections, collectionSeq = state.Favorites, state.Collections, state.CollectionSeq
+    favoriteTrash = state.Trash
+    favoritesLoaded = true
+    return nil
+}
+
+// saveFavorites writes the favorites, collections and trash. Callers must hold
+// favMutex.
+func saveFavorites() error {
+    return saveJSON(favoritesFile, favoritesState{
+        Favorites:     favorites,
+        Collections:   collections,
+        CollectionSeq: collectionSeq,
+        Trash:         favoriteTrash,
+    })
+}
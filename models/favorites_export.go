@@ -0,0 +1,294 @@
+package models
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+const (
+    ImportImported  = "imported"
+    ImportDuplicate = "duplicate"
+    ImportInvalid   = "invalid"
+
+    maxImportRows = 5000
+)
+
+// favoriteCSVHeader is the column order of CSV exports. Imports accept the
+// columns in any order; only id and url are required.
+var favoriteCSVHeader = []string{"id", "url", "collection", "notes", "tags", "breeds", "added_at"}
+
+// FavoriteRow is a favorite as exported and imported. Collection is the
+// collection's name rather than its ID so exports can be imported into
+// another instance; it is empty for the default collection.
+type FavoriteRow struct {
+    ID         string    `json:"id"`
+    URL        string    `json:"url"`
+    Collection string    `json:"collection,omitempty"`
+    Notes      string    `json:"notes,omitempty"`
+    Tags       []string  `json:"tags,omitempty"`
+    Breeds     []string  `json:"breeds,omitempty"`
+    AddedAt    time.Time `json:"added_at,omitempty"`
+}
+
+// ImportReport sums up an import and lists the result of every row.
+type ImportReport struct {
+    Imported   int            `json:"imported"`
+    Duplicates int            `json:"duplicates"`
+    Invalid    int            `json:"invalid"`
+    Results    []ImportResult `json:"results"`
+}
+
+// ImportResult reports what happened to one imported row. Rows are
+// numbered from 1, not counting a CSV header.
+type ImportResult struct {
+    Row     int    `json:"row"`
+    ID      string `json:"id,omitempty"`
+    Status  string `json:"status"`
+    Message string `json:"message,omitempty"`
+}
+
+// ExportFavorites returns every favorite in the manual order.
+func ExportFavorites() ([]FavoriteRow, error) {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    names := make(map[string]string, len(collections))
+    for _, collection := range collections {
+        names[collection.ID] = collection.Name
+    }
+
+    rows := make([]FavoriteRow, 0, len(favorites))
+    for _, fav := range favorites {
+        row := FavoriteRow{
+            ID:         fav.ID,
+            URL:        fav.URL,
+            Collection: names[fav.Collection],
+            Notes:      fav.Notes,
+            Tags:       fav.Tags,
+            AddedAt:    fav.AddedAt,
+        }
+        if fav.Image != nil {
+            for _, breed := range fav.Image.Breeds {
+                row.Breeds = append(row.Breeds, breed.ID)
+            }
+        }
+        rows = append(rows, row)
+    }
+    return rows, nil
+}
+
+// WriteFavoritesCSV writes rows with a header line. Tags and breeds are
+// separated by semicolons.
+func WriteFavoritesCSV(w io.Writer, rows []FavoriteRow) error {
+    out := csv.NewWriter(w)
+    if err := out.Write(favoriteCSVHeader); err != nil {
+        return err
+    }
+    for _, row := range rows {
+        addedAt := ""
+        if !row.AddedAt.IsZero() {
+            addedAt = row.AddedAt.UTC().Format(time.RFC3339)
+        }
+        record := []string{
+            row.ID,
+            row.URL,
+            row.Collection,
+            row.Notes,
+            strings.Join(row.Tags, ";"),
+            strings.Join(row.Breeds, ";"),
+            addedAt,
+        }
+        if err := out.Write(record); err != nil {
+            return err
+        }
+    }
+    out.Flush()
+    return out.Error()
+}
+
+// ParseFavoritesJSON reads an array of rows as written by a JSON export.
+func ParseFavoritesJSON(data []byte) ([]FavoriteRow, error) {
+    var rows []FavoriteRow
+    if err := json.Unmarshal(data, &rows); err != nil {
+        return nil, fmt.Errorf("invalid JSON: %v", err)
+    }
+    if len(rows) > maxImportRows {
+        return nil, fmt.Errorf("at most %d favorites can be imported at once", maxImportRows)
+    }
+    return rows, nil
+}
+
+// ParseFavoritesCSV reads rows as written by a CSV export. Unknown columns
+// are ignored, and breeds and added_at are informational only.
+func ParseFavoritesCSV(data []byte) ([]FavoriteRow, error) {
+    in := csv.NewReader(strings.NewReader(string(data)))
+    in.FieldsPerRecord = -1
+    records, err := in.ReadAll()
+    if err != nil {
+        return nil, fmt.Errorf("invalid CSV: %v", err)
+    }
+    if len(records) == 0 {
+        return nil, fmt.Errorf("invalid CSV: missing header")
+    }
+    if len(records)-1 > maxImportRows {
+        return nil, fmt.Errorf("at most %d favorites can be imported at once", maxImportRows)
+    }
+
+    columns := make(map[string]int)
+    for i, name := range records[0] {
+        columns[strings.ToLower(strings.TrimSpace(name))] = i
+    }
+    for _, required := range []string{"id", "url"} {
+        if _, ok := columns[required]; !ok {
+            return nil, fmt.Errorf("invalid CSV: missing %s column", required)
+        }
+    }
+    field := func(record []string, name string) string {
+        i, ok := columns[name]
+        if !ok || i >= len(record) {
+            return ""
+        }
+        return strings.TrimSpace(record[i])
+    }
+
+    rows := make([]FavoriteRow, 0, len(records)-1)
+    for _, record := range records[1:] {
+        rows = append(rows, FavoriteRow{
+            ID:         field(record, "id"),
+            URL:        field(record, "url"),
+            Collection: field(record, "collection"),
+            Notes:      field(record, "notes"),
+            Tags:       splitList(field(record, "tags")),
+        })
+    }
+    return rows, nil
+}
+
+// ImportFavorites saves each row as SaveFavorite would, so images that are
+// already favorites are reported as duplicates and left untouched. Notes
+// and tags are validated like UpdateFavorite's, and collections that don't
+// exist yet are created. A row is imported whole or not at all, and the
+// favorites are saved once at the end.
+func ImportFavorites(rows []FavoriteRow) (ImportReport, error) {
+    images := make(map[string]*Cat)
+    for _, row := range rows {
+        id := strings.TrimSpace(row.ID)
+        if image := cachedImage(id); image != nil {
+            images[id] = image
+        }
+    }
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return ImportReport{}, err
+    }
+    undo := snapshotFavorites()
+
+    report := ImportReport{Results: make([]ImportResult, 0, len(rows))}
+    for i, row := range rows {
+        result := ImportResult{Row: i + 1, ID: strings.TrimSpace(row.ID)}
+        err := importFavorite(row, images[result.ID])
+        switch {
+        case err == nil:
+            result.Status = ImportImported
+            report.Imported++
+        case errors.Is(err, ErrAlreadyFavorite):
+            result.Status = ImportDuplicate
+            result.Message = err.Error()
+            report.Duplicates++
+        default:
+            result.Status = ImportInvalid
+            result.Message = err.Error()
+            report.Invalid++
+        }
+        report.Results = append(report.Results, result)
+    }
+
+    if report.Imported == 0 {
+        return report, nil
+    }
+    if err := saveFavorites(); err != nil {
+        undo()
+        return ImportReport{}, err
+    }
+    return report, nil
+}
+
+// importFavorite adds one row to the favorites without saving. Callers
+// must hold favMutex.
+func importFavorite(row FavoriteRow, image *Cat) error {
+    row.ID = strings.TrimSpace(row.ID)
+    if row.ID == "" {
+        return fmt.Errorf("id is required")
+    }
+    if _, err := checkImageURL(row.URL); err != nil {
+        return err
+    }
+    tags, err := normalizeTags(row.Tags)
+    if err != nil {
+        return err
+    }
+    if len(row.Notes) > maxNotesLength {
+        return fmt.Errorf("notes must be at most %d characters", maxNotesLength)
+    }
+    for _, fav := range favorites {
+        if fav.ID == row.ID {
+            return ErrAlreadyFavorite
+        }
+    }
+
+    collection := ""
+    if name := strings.TrimSpace(row.Collection); name != "" && !strings.EqualFold(name, defaultCollectionName) {
+        if collection, err = collectionNamed(name); err != nil {
+            return err
+        }
+    }
+    if err := addFavorite(row.ID, row.URL, image); err != nil {
+        return err
+    }
+    fav := &favorites[len(favorites)-1]
+    fav.Notes = strings.TrimSpace(row.Notes)
+    fav.Tags = tags
+    fav.Collection = collection
+    return nil
+}
+
+// collectionNamed returns the ID of the collection with the name, creating
+// it if there is none. Callers must hold favMutex.
+func collectionNamed(name string) (string, error) {
+    for _, collection := range collections {
+        if strings.EqualFold(collection.Name, name) {
+            return collection.ID, nil
+        }
+    }
+    name, err := validCollectionName(name, "")
+    if err != nil {
+        return "", err
+    }
+    collectionSeq++
+    collection := Collection{ID: strconv.Itoa(collectionSeq), Name: name, CreatedAt: time.Now()}
+    collections = append(collections, collection)
+    return collection.ID, nil
+}
+
+func splitList(value string) []string {
+    result := []string{}
+    for _, item := range strings.Split(value, ";") {
+        if item = strings.TrimSpace(item); item != "" {
+            result = append(result, item)
+        }
+    }
+    return result
+}
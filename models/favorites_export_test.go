@@ -0,0 +1,224 @@
+package models
+
+import (
+    "archive/zip"
+    "bytes"
+    "encoding/json"
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestFavoritesCSVRoundTrip(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+    notes, tags := "Has a comma, and \"quotes\"", []string{"sleepy", "orange"}
+    UpdateFavorite("a", FavoriteUpdate{Notes: &notes, Tags: &tags})
+    desk, _ := CreateCollection("Desk")
+    AddToCollection(desk.ID, "b", "")
+
+    var buf bytes.Buffer
+    exported, err := ExportFavorites()
+    assert.NoError(t, err)
+    assert.NoError(t, WriteFavoritesCSV(&buf, exported))
+
+    rows, err := ParseFavoritesCSV(buf.Bytes())
+    assert.NoError(t, err)
+    assert.Equal(t, 2, len(rows))
+    assert.Equal(t, notes, rows[0].Notes)
+    assert.Equal(t, tags, rows[0].Tags)
+    assert.Equal(t, "Desk", rows[1].Collection)
+
+    _, err = ParseFavoritesCSV([]byte("url\nhttp://example.com/a.jpg\n"))
+    assert.Error(t, err, "the id column is required")
+}
+
+func TestImportFavorites(t *testing.T) {
+    resetCollections()
+    SaveFavorite("existing", "https://cdn2.thecatapi.com/images/existing.jpg")
+
+    rows, err := ParseFavoritesJSON([]byte(`[
+        {"id": "existing", "url": "https://cdn2.thecatapi.com/images/existing.jpg"},
+        {"id": "new", "url": "https://cdn2.thecatapi.com/images/new.jpg", "collection": "Desk", "tags": ["Orange"]},
+        {"id": "new", "url": "https://cdn2.thecatapi.com/images/new.jpg"},
+        {"id": "", "url": "https://cdn2.thecatapi.com/images/blank.jpg"},
+        {"id": "bad", "url": "javascript:alert(1)"},
+        {"id": "metadata", "url": "http://169.254.169.254/latest/meta-data"}
+    ]`))
+    assert.NoError(t, err)
+
+    report, err := ImportFavorites(rows)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, report.Imported)
+    assert.Equal(t, 2, report.Duplicates)
+    assert.Equal(t, 3, report.Invalid)
+    statuses := []string{}
+    for _, result := range report.Results {
+        statuses = append(statuses, result.Status)
+    }
+    assert.Equal(t, []string{ImportDuplicate, ImportImported, ImportDuplicate, ImportInvalid, ImportInvalid, ImportInvalid}, statuses)
+    assert.Equal(t, 5, report.Results[4].Row)
+
+    favs, _ := QueryFavorites(FavoriteQuery{Tag: "orange"})
+    assert.Equal(t, []string{"new"}, favoriteIDs(favs))
+    exported, _ := ExportFavorites()
+    assert.Equal(t, "Desk", exported[1].Collection, "missing collections are created")
+
+    _, err = ParseFavoritesJSON([]byte(`{"id": "a"}`))
+    assert.Error(t, err)
+}
+
+func TestImportFavoritesKeepsNothingWhenTheSaveFails(t *testing.T) {
+    dir := withDataDir(t)
+    resetCollections()
+    assert.NoError(t, os.Mkdir(filepath.Join(dir, favoritesFile), 0755))
+
+    _, err := ImportFavorites([]FavoriteRow{
+        {ID: "a", URL: "https://cdn2.thecatapi.com/images/a.jpg", Collection: "Desk"},
+    })
+    assert.Error(t, err)
+    assert.Empty(t, GetFavorites())
+    assert.Equal(t, 1, len(GetCollections()), "only the default collection is left")
+}
+
+func TestZipExport(t *testing.T) {
+    images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/missing.jpg" {
+            http.NotFound(w, r)
+            return
+        }
+        w.Header().Set("Content-Type", "image/png")
+        w.Write([]byte("image bytes"))
+    }))
+    defer images.Close()
+    withImageHosts(t, "127.0.0.1")
+    // The test server listens on loopback, which the export client refuses.
+    original := exportClient
+    exportClient = &http.Client{CheckRedirect: checkImageRedirect}
+    defer func() { exportClient = original }()
+
+    resetCollections()
+    SaveFavorite("a", images.URL+"/a.jpg")
+    SaveFavorite("b", images.URL+"/b")
+    SaveFavorite("c", images.URL+"/missing.jpg")
+
+    job, err := StartFavoritesZipExport()
+    assert.NoError(t, err)
+    _, err = ExportJobFile(job.ID)
+    for i := 0; err != nil && i < 100; i++ {
+        time.Sleep(20 * time.Millisecond)
+        _, err = ExportJobFile(job.ID)
+    }
+    path, err := ExportJobFile(job.ID)
+    assert.NoError(t, err)
+
+    job, _ = GetExportJob(job.ID)
+    assert.Equal(t, ExportDone, job.Status)
+    assert.Equal(t, 3, job.Count)
+    assert.Equal(t, 1, job.Missing)
+
+    archive, err := zip.OpenReader(path)
+    assert.NoError(t, err)
+    defer archive.Close()
+    names := []string{}
+    var manifest []exportManifestEntry
+    for _, file := range archive.File {
+        names = append(names, file.Name)
+        if file.Name == "manifest.json" {
+            r, _ := file.Open()
+            json.NewDecoder(r).Decode(&manifest)
+            r.Close()
+        }
+    }
+    assert.ElementsMatch(t, []string{"images/a.jpg", "images/b.png", "manifest.json"}, names)
+    assert.Equal(t, 3, len(manifest))
+    assert.NotEmpty(t, manifest[2].Error)
+
+    _, err = GetExportJob("missing")
+    assert.Error(t, err)
+}
+
+func withImageHosts(t *testing.T, hosts string) {
+    web.AppConfig.Set("image_hosts", hosts)
+    t.Cleanup(func() {
+        web.AppConfig.Set("image_hosts", defaultImageHosts)
+    })
+}
+
+func TestCheckImageURL(t *testing.T) {
+    _, err := checkImageURL("https://cdn2.thecatapi.com/images/abc.jpg")
+    assert.NoError(t, err)
+    for _, raw := range []string{
+        "http://169.254.169.254/latest/meta-data",
+        "http://localhost:8080/api/admin",
+        "https://cdn2.thecatapi.com.evil.example/abc.jpg",
+        "file:///etc/passwd",
+    } {
+        _, err := checkImageURL(raw)
+        assert.Error(t, err, raw)
+    }
+}
+
+func TestImageClientRefusesPrivateAddresses(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("secret"))
+    }))
+    defer server.Close()
+
+    _, err := newImageClient(time.Second).Get(server.URL)
+    assert.Error(t, err, "loopback addresses are refused")
+
+    for _, ip := range []string{"10.0.0.1", "192.168.1.1", "169.254.169.254", "::1", "fe80::1", "0.0.0.0"} {
+        assert.False(t, publicAddress(net.ParseIP(ip)), ip)
+    }
+    assert.True(t, publicAddress(net.ParseIP("151.101.1.1")))
+}
+
+func TestImageClientRefusesRedirectsToOtherHosts(t *testing.T) {
+    redirect := func(target string) error {
+        from, _ := http.NewRequest(http.MethodGet, "https://cdn2.thecatapi.com/images/a.jpg", nil)
+        to, _ := http.NewRequest(http.MethodGet, target, nil)
+        return checkImageRedirect(to, []*http.Request{from})
+    }
+    assert.NoError(t, redirect("https://cdn2.thecatapi.com/images/b.jpg"))
+    assert.Error(t, redirect("http://169.254.169.254/latest/meta-data"))
+}
+
+func TestExportJobsSurviveRestart(t *testing.T) {
+    withDataDir(t)
+    restart := func() {
+        exportMutex.Lock()
+        exportJobs, exportJobsLoaded = make(map[string]*ExportJob), false
+        exportMutex.Unlock()
+    }
+    restart()
+
+    now := time.Now()
+    exportMutex.Lock()
+    exportJobs["old"] = &ExportJob{ID: "old", Status: ExportDone, CreatedAt: now.Add(-49 * time.Hour), FinishedAt: now.Add(-48 * time.Hour)}
+    exportJobs["busy"] = &ExportJob{ID: "busy", Status: ExportRunning, CreatedAt: now}
+    exportJobsLoaded = true
+    assert.NoError(t, saveJSON(exportJobsFile, exportJobs))
+    exportMutex.Unlock()
+    assert.NoError(t, os.MkdirAll(filepath.Dir(exportPath("old")), 0755))
+    assert.NoError(t, os.WriteFile(exportPath("old"), []byte("zip"), 0644))
+
+    restart()
+    busy, err := GetExportJob("busy")
+    assert.NoError(t, err)
+    assert.Equal(t, ExportFailed, busy.Status, "a job cut off by the restart doesn't stay running")
+
+    restart()
+    purged, err := PurgeExportJobs(now)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, purged)
+    _, err = os.Stat(exportPath("old"))
+    assert.True(t, os.IsNotExist(err), "the ZIP of an export from before the restart is deleted")
+}
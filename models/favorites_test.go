@@ -0,0 +1,167 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func TestUpdateFavorite(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+
+    notes := "  Asleep on the keyboard "
+    tags := []string{"Sleepy", " orange", "sleepy", ""}
+    fav, err := UpdateFavorite("a", FavoriteUpdate{Notes: &notes, Tags: &tags})
+    assert.NoError(t, err)
+    assert.Equal(t, "Asleep on the keyboard", fav.Notes)
+    assert.Equal(t, []string{"sleepy", "orange"}, fav.Tags)
+    assert.False(t, fav.AddedAt.IsZero())
+
+    // Fields left out are kept.
+    fav, err = UpdateFavorite("a", FavoriteUpdate{})
+    assert.NoError(t, err)
+    assert.Equal(t, "Asleep on the keyboard", fav.Notes)
+
+    _, err = UpdateFavorite("missing", FavoriteUpdate{Notes: &notes})
+    assert.Error(t, err)
+
+    tooMany := make([]string, maxFavoriteTags+1)
+    for i := range tooMany {
+        tooMany[i] = string(rune('a' + i))
+    }
+    _, err = UpdateFavorite("a", FavoriteUpdate{Tags: &tooMany})
+    assert.Error(t, err)
+}
+
+func TestQueryFavoritesByTagAndText(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+    SaveFavorite("c", "http://example.com/c.jpg")
+    notes := "Sitting in the window"
+    tagsA, tagsB := []string{"sleepy", "orange"}, []string{"orange"}
+    UpdateFavorite("a", FavoriteUpdate{Tags: &tagsA})
+    UpdateFavorite("b", FavoriteUpdate{Tags: &tagsB, Notes: &notes})
+
+    orange, _ := QueryFavorites(FavoriteQuery{Tag: "ORANGE"})
+    assert.Equal(t, []string{"a", "b"}, favoriteIDs(orange))
+
+    window, _ := QueryFavorites(FavoriteQuery{Q: "window"})
+    assert.Equal(t, []string{"b"}, favoriteIDs(window))
+
+    sleepy, _ := QueryFavorites(FavoriteQuery{Tag: "orange", Q: "slee"})
+    assert.Equal(t, []string{"a"}, favoriteIDs(sleepy))
+
+    assert.Equal(t, []TagCount{{Tag: "orange", Count: 2}, {Tag: "sleepy", Count: 1}}, GetFavoriteTags())
+}
+
+func TestQueryFavoritesSorted(t *testing.T) {
+    resetCollections()
+    resetVotes()
+    defer resetVotes()
+    now := time.Now()
+    favMutex.Lock()
+    favorites = []FavoriteImage{
+        {ID: "a", AddedAt: now.Add(-time.Hour), Image: &Cat{ID: "a", Breeds: []Breed{{ID: "bomb", Name: "Bombay"}}}},
+        {ID: "b", AddedAt: now},
+        {ID: "c", AddedAt: now.Add(-2 * time.Hour), Image: &Cat{ID: "c", Breeds: []Breed{{ID: "abys", Name: "Abyssinian"}}}},
+    }
+    favMutex.Unlock()
+    RecordVote("alice", VoteRequest{ImageID: "c", Vote: VoteLove})
+    RecordVote("bob", VoteRequest{ImageID: "b", Vote: VoteLike})
+    RecordVote("bob", VoteRequest{ImageID: "a", Vote: VoteDislike})
+
+    manual, _ := QueryFavorites(FavoriteQuery{})
+    assert.Equal(t, []string{"a", "b", "c"}, favoriteIDs(manual))
+    added, _ := QueryFavorites(FavoriteQuery{Sort: FavoriteSortAdded})
+    assert.Equal(t, []string{"b", "a", "c"}, favoriteIDs(added))
+    breed, _ := QueryFavorites(FavoriteQuery{Sort: FavoriteSortBreed})
+    assert.Equal(t, []string{"c", "a", "b"}, favoriteIDs(breed), "favorites without a breed go last")
+    voted, _ := QueryFavorites(FavoriteQuery{Sort: FavoriteSortVotes})
+    assert.Equal(t, []string{"c", "b", "a"}, favoriteIDs(voted))
+
+    _, err := QueryFavorites(FavoriteQuery{Sort: "random"})
+    assert.Error(t, err)
+}
+
+func TestPageFavorites(t *testing.T) {
+    resetCollections()
+    for _, id := range []string{"a", "b", "c", "d", "e"} {
+        SaveFavorite(id, "http://example.com/"+id+".jpg")
+    }
+
+    ids := []string{}
+    cursor := ""
+    for pages := 0; pages < 5; pages++ {
+        page, err := PageFavorites(FavoriteQuery{Limit: 2, Cursor: cursor})
+        assert.NoError(t, err)
+        ids = append(ids, favoriteIDs(page.Items)...)
+        if cursor = page.NextCursor; cursor == "" {
+            break
+        }
+    }
+    assert.Equal(t, []string{"a", "b", "c", "d", "e"}, ids)
+
+    _, err := PageFavorites(FavoriteQuery{Limit: 2, Cursor: "not a cursor"})
+    assert.Error(t, err)
+}
+
+func TestPageFavoritesAfterTrashedFavorite(t *testing.T) {
+    resetCollections()
+    for _, id := range []string{"a", "b", "c", "d", "e"} {
+        SaveFavorite(id, "http://example.com/"+id+".jpg")
+    }
+
+    page, err := PageFavorites(FavoriteQuery{Limit: 2})
+    assert.NoError(t, err)
+    assert.NoError(t, TrashFavorite("", "b"))
+    page, err = PageFavorites(FavoriteQuery{Limit: 2, Cursor: page.NextCursor})
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"c", "d"}, favoriteIDs(page.Items))
+}
+
+func TestPageFavoritesByVotesWhileScoresChange(t *testing.T) {
+    resetCollections()
+    resetVotes()
+    defer resetVotes()
+    for _, id := range []string{"a", "b", "c", "d"} {
+        SaveFavorite(id, "http://example.com/"+id+".jpg")
+    }
+    RecordVote("alice", VoteRequest{ImageID: "a", Vote: VoteLove})
+    RecordVote("alice", VoteRequest{ImageID: "b", Vote: VoteLike})
+
+    page, err := PageFavorites(FavoriteQuery{Sort: FavoriteSortVotes, Limit: 2})
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"a", "b"}, favoriteIDs(page.Items))
+
+    // b drops below c and d; the next page still holds the rest.
+    RecordVote("bob", VoteRequest{ImageID: "b", Vote: VoteDislike})
+    RecordVote("carol", VoteRequest{ImageID: "b", Vote: VoteDislike})
+    page, err = PageFavorites(FavoriteQuery{Sort: FavoriteSortVotes, Limit: 2, Cursor: page.NextCursor})
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"c", "d"}, favoriteIDs(page.Items))
+
+    _, err = PageFavorites(FavoriteQuery{Sort: FavoriteSortAdded, Limit: 2, Cursor: page.NextCursor})
+    assert.Error(t, err, "a cursor belongs to its sort")
+}
+
+func TestReorderFavorites(t *testing.T) {
+    resetCollections()
+    for _, id := range []string{"a", "b", "c", "d"} {
+        SaveFavorite(id, "http://example.com/"+id+".jpg")
+    }
+
+    assert.NoError(t, ReorderFavorites([]string{"c", "a"}))
+    assert.Equal(t, []string{"c", "a", "b", "d"}, favoriteIDs(GetFavorites()))
+
+    assert.Error(t, ReorderFavorites([]string{"b", "missing"}))
+    assert.Error(t, ReorderFavorites([]string{"b", "b"}))
+    assert.Equal(t, []string{"c", "a", "b", "d"}, favoriteIDs(GetFavorites()), "a rejected order changes nothing")
+
+    // The order survives a restart.
+    favMutex.Lock()
+    favorites, favoritesLoaded = nil, false
+    favMutex.Unlock()
+    assert.Equal(t, []string{"c", "a", "b", "d"}, favoriteIDs(GetFavorites()))
+}
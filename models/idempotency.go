@@ -0,0 +1,233 @@
+package models
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/server/web"
+    "sync"
+    "time"
+)
+
+const (
+    // idempotencyFile is the stored responses as an append-only log of
+    // idempotencyRecords, so storing one doesn't rewrite all the others.
+    idempotencyFile = "idempotency.log"
+
+    maxIdempotencyKey = 255
+
+    // A request still unfinished after this long is assumed to have died
+    // and its key can be claimed again.
+    idempotencyInFlightTimeout = time.Minute
+)
+
+// ErrIdempotencyKeyReused is returned when a key comes back with a
+// different request than the one it was first used for.
+var ErrIdempotencyKeyReused = fmt.Errorf("idempotency key was already used for a different request")
+
+// IdempotentResponse is the stored response replayed for a retried key.
+type IdempotentResponse struct {
+    Status int             `json:"status"`
+    Body   json.RawMessage `json:"body"`
+}
+
+type idempotencyEntry struct {
+    Fingerprint string              `json:"fingerprint"`
+    Response    *IdempotentResponse `json:"response"`
+    CreatedAt   time.Time           `json:"created_at"`
+
+    // done is closed once the first request has finished; nil for entries
+    // loaded from disk, which are always finished.
+    done chan struct{}
+}
+
+// idempotencyRecord is one line of idempotencyFile.
+type idempotencyRecord struct {
+    ID    string            `json:"id"`
+    Entry *idempotencyEntry `json:"entry"`
+}
+
+var (
+    idempotencyEntries = make(map[string]*idempotencyEntry)
+    idempotencyLoaded  bool
+    idempotencyMutex   sync.Mutex
+
+    // idempotencyLogLines counts the records in idempotencyFile, including
+    // expired ones, so the log is compacted once it is mostly stale.
+    idempotencyLogLines int
+)
+
+// IdempotencyFingerprint identifies a request so a reused key can be told
+// apart from a retry.
+func IdempotencyFingerprint(method string, path string, body []byte) string {
+    sum := sha256.Sum256(body)
+    return method + " " + path + " " + hex.EncodeToString(sum[:])
+}
+
+// ClaimIdempotencyKey is called before handling a request that carries a
+// key. It returns the stored response when the request is a retry, waiting
+// for the first request if that is still running. When it returns nil the
+// caller owns the key and must call CompleteIdempotencyKey or
+// ReleaseIdempotencyKey.
+func ClaimIdempotencyKey(scope string, key string, fingerprint string) (*IdempotentResponse, error) {
+    if len(key) > maxIdempotencyKey {
+        return nil, fmt.Errorf("idempotency key must be at most %d characters", maxIdempotencyKey)
+    }
+    id := scope + "\x00" + key
+
+    for {
+        idempotencyMutex.Lock()
+        if err := loadIdempotency(); err != nil {
+            idempotencyMutex.Unlock()
+            return nil, err
+        }
+
+        now := time.Now()
+        entry, ok := idempotencyEntries[id]
+        if ok && entry.Response == nil && now.Sub(entry.CreatedAt) > idempotencyInFlightTimeout {
+            ok = false
+        }
+        if ok && entry.Response != nil && now.Sub(entry.CreatedAt) > idempotencyRetention() {
+            ok = false
+        }
+        if !ok {
+            idempotencyEntries[id] = &idempotencyEntry{
+                Fingerprint: fingerprint,
+                CreatedAt:   now,
+                done:        make(chan struct{}),
+            }
+            idempotencyMutex.Unlock()
+            return nil, nil
+        }
+        if entry.Fingerprint != fingerprint {
+            idempotencyMutex.Unlock()
+            return nil, ErrIdempotencyKeyReused
+        }
+        if entry.Response != nil {
+            response := *entry.Response
+            idempotencyMutex.Unlock()
+            return &response, nil
+        }
+
+        // The first request is still running: wait for it and look again.
+        done := entry.done
+        idempotencyMutex.Unlock()
+        select {
+        case <-done:
+        case <-time.After(idempotencyInFlightTimeout):
+        }
+    }
+}
+
+// CompleteIdempotencyKey stores the response of the request that claimed
+// the key, so retries within idempotency_retention get it replayed.
+func CompleteIdempotencyKey(scope string, key string, status int, body interface{}) error {
+    data, err := json.Marshal(body)
+    if err != nil {
+        ReleaseIdempotencyKey(scope, key)
+        return err
+    }
+
+    idempotencyMutex.Lock()
+    defer idempotencyMutex.Unlock()
+
+    entry, ok := idempotencyEntries[scope+"\x00"+key]
+    if !ok || entry.Response != nil {
+        return nil
+    }
+    entry.Response = &IdempotentResponse{Status: status, Body: data}
+    close(entry.done)
+
+    if err := appendJSONLines(idempotencyFile, idempotencyRecord{ID: scope + "\x00" + key, Entry: entry}); err != nil {
+        return err
+    }
+    idempotencyLogLines++
+    if idempotencyLogLines > 2*len(idempotencyEntries)+100 {
+        pruneIdempotency(time.Now())
+        return saveIdempotency()
+    }
+    return nil
+}
+
+// ReleaseIdempotencyKey forgets a claimed key without storing a response,
+// for requests whose outcome shouldn't be replayed. Retries run again.
+func ReleaseIdempotencyKey(scope string, key string) {
+    idempotencyMutex.Lock()
+    defer idempotencyMutex.Unlock()
+
+    id := scope + "\x00" + key
+    if entry, ok := idempotencyEntries[id]; ok && entry.Response == nil {
+        delete(idempotencyEntries, id)
+        close(entry.done)
+    }
+}
+
+// pruneIdempotency drops stored responses older than the retention window.
+// Callers must hold idempotencyMutex.
+func pruneIdempotency(now time.Time) {
+    retention := idempotencyRetention()
+    for id, entry := range idempotencyEntries {
+        if entry.Response != nil && now.Sub(entry.CreatedAt) > retention {
+            delete(idempotencyEntries, id)
+        }
+    }
+}
+
+func idempotencyRetention() time.Duration {
+    retention, err := time.ParseDuration(web.AppConfig.DefaultString("idempotency_retention", "24h"))
+    if err != nil || retention <= 0 {
+        retention = 24 * time.Hour
+    }
+    return retention
+}
+
+// loadIdempotency reads the stored responses on first use, compacting the
+// log when it holds expired responses. Callers must hold idempotencyMutex.
+func loadIdempotency() error {
+    if idempotencyLoaded {
+        return nil
+    }
+
+    lines := 0
+    err := loadJSONLines(idempotencyFile, func(line []byte) error {
+        var record idempotencyRecord
+        if err := json.Unmarshal(line, &record); err != nil {
+            return err
+        }
+        if record.Entry != nil && record.Entry.Response != nil {
+            idempotencyEntries[record.ID] = record.Entry
+        }
+        lines++
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+
+    idempotencyLogLines = lines
+    pruneIdempotency(time.Now())
+    if len(idempotencyEntries) < lines {
+        if err := saveIdempotency(); err != nil {
+            return err
+        }
+    }
+    idempotencyLoaded = true
+    return nil
+}
+
+// saveIdempotency rewrites the log with just the finished entries. Callers
+// must hold idempotencyMutex.
+func saveIdempotency() error {
+    records := []interface{}{}
+    for id, entry := range idempotencyEntries {
+        if entry.Response != nil {
+            records = append(records, idempotencyRecord{ID: id, Entry: entry})
+        }
+    }
+    if err := saveJSONLines(idempotencyFile, records); err != nil {
+        return err
+    }
+    idempotencyLogLines = len(records)
+    return nil
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+    "bytes"
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "path/filepath"
+    "sync"
+    "testing"
+    "time"
+)
+
+func resetIdempotency() {
+    idempotencyMutex.Lock()
+    idempotencyEntries = make(map[string]*idempotencyEntry)
+    idempotencyLoaded = true
+    idempotencyMutex.Unlock()
+}
+
+func TestIdempotencyKeyReplay(t *testing.T) {
+    resetIdempotency()
+    fingerprint := IdempotencyFingerprint("POST", "/api/vote", []byte(`{"vote":"love"}`))
+
+    stored, err := ClaimIdempotencyKey("alice", "k1", fingerprint)
+    assert.NoError(t, err)
+    assert.Nil(t, stored, "the first request runs")
+    assert.NoError(t, CompleteIdempotencyKey("alice", "k1", 200, map[string]string{"status": "success"}))
+
+    stored, err = ClaimIdempotencyKey("alice", "k1", fingerprint)
+    assert.NoError(t, err)
+    assert.Equal(t, 200, stored.Status)
+    assert.JSONEq(t, `{"status":"success"}`, string(stored.Body))
+
+    // Keys are scoped to the caller.
+    stored, err = ClaimIdempotencyKey("bob", "k1", fingerprint)
+    assert.NoError(t, err)
+    assert.Nil(t, stored)
+
+    other := IdempotencyFingerprint("POST", "/api/vote", []byte(`{"vote":"like"}`))
+    _, err = ClaimIdempotencyKey("alice", "k1", other)
+    assert.Equal(t, ErrIdempotencyKeyReused, err)
+
+    // Stored responses survive a restart.
+    idempotencyMutex.Lock()
+    idempotencyEntries, idempotencyLoaded = make(map[string]*idempotencyEntry), false
+    idempotencyMutex.Unlock()
+    stored, _ = ClaimIdempotencyKey("alice", "k1", fingerprint)
+    assert.NotNil(t, stored)
+}
+
+func TestIdempotencyKeyWaitsForFirstRequest(t *testing.T) {
+    resetIdempotency()
+    fingerprint := IdempotencyFingerprint("POST", "/api/vote", nil)
+    ClaimIdempotencyKey("alice", "k1", fingerprint)
+
+    var wg sync.WaitGroup
+    var retried *IdempotentResponse
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        retried, _ = ClaimIdempotencyKey("alice", "k1", fingerprint)
+    }()
+    time.Sleep(20 * time.Millisecond)
+    CompleteIdempotencyKey("alice", "k1", 201, "created")
+    wg.Wait()
+
+    assert.NotNil(t, retried)
+    assert.Equal(t, 201, retried.Status)
+}
+
+func TestIdempotencyKeyReleaseAndExpiry(t *testing.T) {
+    resetIdempotency()
+    fingerprint := IdempotencyFingerprint("DELETE", "/api/favorites/a", nil)
+
+    ClaimIdempotencyKey("alice", "k1", fingerprint)
+    ReleaseIdempotencyKey("alice", "k1")
+    stored, err := ClaimIdempotencyKey("alice", "k1", fingerprint)
+    assert.NoError(t, err)
+    assert.Nil(t, stored, "a released key runs again")
+    CompleteIdempotencyKey("alice", "k1", 200, "done")
+
+    web.AppConfig.Set("idempotency_retention", "1ms")
+    defer web.AppConfig.Set("idempotency_retention", "24h")
+    time.Sleep(5 * time.Millisecond)
+    stored, _ = ClaimIdempotencyKey("alice", "k1", fingerprint)
+    assert.Nil(t, stored, "expired responses aren't replayed")
+
+    _, err = ClaimIdempotencyKey("alice", string(make([]byte, maxIdempotencyKey+1)), fingerprint)
+    assert.Error(t, err)
+}
+
+func TestIdempotencyLogIsAppendedAndCompacted(t *testing.T) {
+    dir := withDataDir(t)
+    resetIdempotency()
+    fingerprint := IdempotencyFingerprint("POST", "/api/vote", nil)
+    for _, key := range []string{"k1", "k2", "k3"} {
+        ClaimIdempotencyKey("alice", key, fingerprint)
+        assert.NoError(t, CompleteIdempotencyKey("alice", key, 200, key))
+    }
+    data, err := ioutil.ReadFile(filepath.Join(dir, idempotencyFile))
+    assert.NoError(t, err)
+    assert.Equal(t, 3, bytes.Count(data, []byte("\n")), "one line per stored response")
+
+    // Expired responses are dropped from the log on the next load.
+    idempotencyMutex.Lock()
+    idempotencyEntries["alice\x00k1"].CreatedAt = time.Now().Add(-48 * time.Hour)
+    saveIdempotency()
+    idempotencyEntries, idempotencyLoaded = make(map[string]*idempotencyEntry), false
+    idempotencyMutex.Unlock()
+
+    stored, _ := ClaimIdempotencyKey("alice", "k2", fingerprint)
+    assert.NotNil(t, stored)
+    data, _ = ioutil.ReadFile(filepath.Join(dir, idempotencyFile))
+    assert.Equal(t, 2, bytes.Count(data, []byte("\n")))
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+    "sync"
+)
+
+// maxCachedImages bounds the cache of recently served images; the oldest
+// entries are evicted first.
+const maxCachedImages = 1000
+
+var (
+    imageCache      = make(map[string]Cat)
+    imageCacheOrder []string
+    imageCacheMutex sync.Mutex
+)
+
+// rememberCats caches images handed out to clients so later votes on them
+// can be attributed to their breeds.
+func rememberCats(cats []Cat) {
+    imageCacheMutex.Lock()
+    defer imageCacheMutex.Unlock()
+
+    for _, cat := range cats {
+        if _, ok := imageCache[cat.ID]; !ok {
+            imageCacheOrder = append(imageCacheOrder, cat.ID)
+        }
+        imageCache[cat.ID] = cat
+    }
+
+    for len(imageCacheOrder) > maxCachedImages {
+        delete(imageCache, imageCacheOrder[0])
+        imageCacheOrder = imageCacheOrder[1:]
+    }
+}
+
+// LookupCat returns a recently served image by ID.
+func LookupCat(id string) (Cat, bool) {
+    imageCacheMutex.Lock()
+    defer imageCacheMutex.Unlock()
+
+    cat, ok := imageCache[id]
+    return cat, ok
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+    "fmt"
+    "github.com/beego/beego/v2/server/web"
+    "net"
+    "net/http"
+    "net/url"
+    "strings"
+    "syscall"
+    "time"
+)
+
+// defaultImageHosts is where TheCatAPI serves its images.
+const defaultImageHosts = "cdn2.thecatapi.com"
+
+// imageHosts returns the hosts images may be imported from and downloaded
+// from, as set by image_hosts.
+func imageHosts() []string {
+    hosts := []string{}
+    for _, host := range strings.Split(web.AppConfig.DefaultString("image_hosts", defaultImageHosts), ",") {
+        if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+            hosts = append(hosts, host)
+        }
+    }
+    return hosts
+}
+
+// checkImageURL parses an image URL and makes sure it is served over HTTP
+// from one of the image hosts.
+func checkImageURL(raw string) (*url.URL, error) {
+    target, err := url.Parse(raw)
+    if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+        return nil, fmt.Errorf("invalid url: %q", raw)
+    }
+    if !containsString(imageHosts(), strings.ToLower(target.Hostname())) {
+        return nil, fmt.Errorf("images must be hosted on %s", strings.Join(imageHosts(), ", "))
+    }
+    return target, nil
+}
+
+// newImageClient returns a client for downloading images. It refuses to
+// connect to private, loopback and link-local addresses, whatever the
+// host name resolves to, and doesn't follow redirects to other hosts.
+func newImageClient(timeout time.Duration) *http.Client {
+    dialer := &net.Dialer{Timeout: timeout, Control: refusePrivateAddress}
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.Proxy = nil
+    transport.DialContext = dialer.DialContext
+    return &http.Client{
+        Timeout:       timeout,
+        Transport:     transport,
+        CheckRedirect: checkImageRedirect,
+    }
+}
+
+func checkImageRedirect(req *http.Request, via []*http.Request) error {
+    if len(via) >= 10 {
+        return fmt.Errorf("stopped after 10 redirects")
+    }
+    if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+        return fmt.Errorf("refusing redirect to %s", req.URL.Host)
+    }
+    return nil
+}
+
+func refusePrivateAddress(network string, address string, _ syscall.RawConn) error {
+    host, _, err := net.SplitHostPort(address)
+    if err != nil {
+        return err
+    }
+    ip := net.ParseIP(host)
+    if ip == nil || !publicAddress(ip) {
+        return fmt.Errorf("refusing to connect to %s", host)
+    }
+    return nil
+}
+
+func publicAddress(ip net.IP) bool {
+    return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+        ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+        ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
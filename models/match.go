@@ -0,0 +1,212 @@
+package models
+
+import (
+    "fmt"
+    "math"
+    "sort"
+)
+
+// MatchPreference is a desired 1–5 trait score and how much it matters.
+// A zero weight counts as 1.
+type MatchPreference struct {
+    Value  int     `json:"value"`
+    Weight float64 `json:"weight"`
+}
+
+// MatchToggle is a yes/no requirement such as allergies.
+type MatchToggle struct {
+    Enabled bool    `json:"enabled"`
+    Weight  float64 `json:"weight"`
+}
+
+type MatchRequest struct {
+    Energy        *MatchPreference `json:"energy"`
+    Grooming      *MatchPreference `json:"grooming"`
+    ChildFriendly *MatchPreference `json:"child_friendly"`
+    DogFriendly   *MatchPreference `json:"dog_friendly"`
+    Allergies     *MatchToggle     `json:"allergies"`
+    Apartment     *MatchToggle     `json:"apartment"`
+    Limit         int              `json:"limit"`
+}
+
+type MatchReason struct {
+    Preference  string  `json:"preference"`
+    Score       float64 `json:"score"`
+    Weight      float64 `json:"weight"`
+    Explanation string  `json:"explanation"`
+}
+
+type BreedMatch struct {
+    ID      string        `json:"id"`
+    Name    string        `json:"name"`
+    Score   float64       `json:"score"`
+    Reasons []MatchReason `json:"reasons"`
+}
+
+// matchCriterion scores one breed against one preference, returning a
+// value between 0 and 1 and a sentence explaining it.
+type matchCriterion struct {
+    name   string
+    weight float64
+    score  func(Breed) (float64, string)
+}
+
+// MatchBreeds ranks the catalogue against the given preferences. Each
+// breed's score is the weighted mean of its per-preference scores.
+func MatchBreeds(req MatchRequest) ([]BreedMatch, error) {
+    criteria, err := req.criteria()
+    if err != nil {
+        return nil, err
+    }
+
+    breeds, err := GetBreedCatalog()
+    if err != nil {
+        return nil, err
+    }
+
+    matches := []BreedMatch{}
+    for _, breed := range breeds {
+        total, weights := 0.0, 0.0
+        reasons := make([]MatchReason, 0, len(criteria))
+        for _, c := range criteria {
+            score, explanation := c.score(breed)
+            total += score * c.weight
+            weights += c.weight
+            reasons = append(reasons, MatchReason{
+                Preference:  c.name,
+                Score:       math.Round(score*100) / 100,
+                Weight:      c.weight,
+                Explanation: explanation,
+            })
+        }
+
+        // Strongest reasons first, so the UI can show the top few.
+        sort.SliceStable(reasons, func(i, j int) bool {
+            return reasons[i].Score*reasons[i].Weight > reasons[j].Score*reasons[j].Weight
+        })
+        matches = append(matches, BreedMatch{
+            ID:      breed.ID,
+            Name:    breed.Name,
+            Score:   math.Round(total/weights*1000) / 1000,
+            Reasons: reasons,
+        })
+    }
+
+    sort.SliceStable(matches, func(i, j int) bool {
+        if matches[i].Score != matches[j].Score {
+            return matches[i].Score > matches[j].Score
+        }
+        return matches[i].Name < matches[j].Name
+    })
+
+    limit := req.Limit
+    if limit <= 0 {
+        limit = 10
+    }
+    if len(matches) > limit {
+        matches = matches[:limit]
+    }
+    return matches, nil
+}
+
+func (req MatchRequest) criteria() ([]matchCriterion, error) {
+    criteria := []matchCriterion{}
+
+    traits := []struct {
+        name  string
+        label string
+        pref  *MatchPreference
+        value func(Breed) int
+    }{
+        {"energy", "Energy level", req.Energy, func(b Breed) int { return b.EnergyLevel }},
+        {"grooming", "Grooming needs", req.Grooming, func(b Breed) int { return b.Grooming }},
+        {"child_friendly", "Child friendliness", req.ChildFriendly, func(b Breed) int { return b.ChildFriendly }},
+        {"dog_friendly", "Dog friendliness", req.DogFriendly, func(b Breed) int { return b.DogFriendly }},
+    }
+    for _, trait := range traits {
+        if trait.pref == nil {
+            continue
+        }
+        if trait.pref.Value < 1 || trait.pref.Value > 5 {
+            return nil, fmt.Errorf("%s must be between 1 and 5", trait.name)
+        }
+        weight, err := preferenceWeight(trait.name, trait.pref.Weight)
+        if err != nil {
+            return nil, err
+        }
+
+        criteria = append(criteria, matchCriterion{
+            name:   trait.name,
+            weight: weight,
+            score: func(b Breed) (float64, string) {
+                actual := trait.value(b)
+                if actual == 0 {
+                    return 0, fmt.Sprintf("%s is unknown for this breed", trait.label)
+                }
+                diff := math.Abs(float64(actual - trait.pref.Value))
+                if diff == 0 {
+                    return 1, fmt.Sprintf("%s is %d/5, exactly what you asked for", trait.label, actual)
+                }
+                return 1 - diff/4, fmt.Sprintf("%s is %d/5, you asked for %d/5", trait.label, actual, trait.pref.Value)
+            },
+        })
+    }
+
+    if req.Allergies != nil && req.Allergies.Enabled {
+        weight, err := preferenceWeight("allergies", req.Allergies.Weight)
+        if err != nil {
+            return nil, err
+        }
+        criteria = append(criteria, matchCriterion{
+            name:   "allergies",
+            weight: weight,
+            score: func(b Breed) (float64, string) {
+                if b.Hypoallergenic > 0 {
+                    return 1, "Hypoallergenic, a good fit for allergy sufferers"
+                }
+                // Low shedding still helps a little when the breed isn't
+                // hypoallergenic.
+                if b.SheddingLevel > 0 && b.SheddingLevel <= 2 {
+                    return 0.4, fmt.Sprintf("Not hypoallergenic, but sheds little (%d/5)", b.SheddingLevel)
+                }
+                return 0, "Not hypoallergenic"
+            },
+        })
+    }
+
+    if req.Apartment != nil && req.Apartment.Enabled {
+        weight, err := preferenceWeight("apartment", req.Apartment.Weight)
+        if err != nil {
+            return nil, err
+        }
+        criteria = append(criteria, matchCriterion{
+            name:   "apartment",
+            weight: weight,
+            score: func(b Breed) (float64, string) {
+                score := 0.0
+                if b.Adaptability > 0 {
+                    score = float64(b.Adaptability-1) / 4 / 2
+                }
+                if b.Indoor > 0 {
+                    return score + 0.5, fmt.Sprintf("Happy indoors, adaptability %d/5", b.Adaptability)
+                }
+                return score, fmt.Sprintf("Not an indoor breed, adaptability %d/5", b.Adaptability)
+            },
+        })
+    }
+
+    if len(criteria) == 0 {
+        return nil, fmt.Errorf("at least one preference is required")
+    }
+    return criteria, nil
+}
+
+func preferenceWeight(name string, weight float64) (float64, error) {
+    if weight < 0 {
+        return 0, fmt.Errorf("%s weight must not be negative", name)
+    }
+    if weight == 0 {
+        return 1, nil
+    }
+    return weight, nil
+}
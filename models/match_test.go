@@ -0,0 +1,45 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+)
+
+func TestMatchBreeds(t *testing.T) {
+    calm := traitBreed("calm", 5, 5, 4, 4, 1, 1, 2, 3, 1, 3, 3, 1)
+    calm.Indoor = 1
+    calm.Hypoallergenic = 1
+    busy := traitBreed("busy", 2, 3, 2, 2, 5, 4, 2, 5, 5, 5, 3, 5)
+    SetBreedCatalog([]Breed{busy, calm})
+
+    matches, err := MatchBreeds(MatchRequest{
+        Energy:    &MatchPreference{Value: 1, Weight: 2},
+        Allergies: &MatchToggle{Enabled: true},
+        Apartment: &MatchToggle{Enabled: true},
+    })
+    assert.NoError(t, err)
+    assert.Equal(t, 2, len(matches))
+    assert.Equal(t, "calm", matches[0].ID)
+    assert.Equal(t, 1.0, matches[0].Score)
+    assert.Equal(t, 3, len(matches[0].Reasons))
+    assert.Equal(t, "energy", matches[0].Reasons[0].Preference)
+    assert.True(t, matches[1].Score < 0.2)
+
+    matches, err = MatchBreeds(MatchRequest{Energy: &MatchPreference{Value: 5}, Limit: 1})
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(matches))
+    assert.Equal(t, "busy", matches[0].ID)
+}
+
+func TestMatchBreedsValidation(t *testing.T) {
+    SetBreedCatalog(sampleBreeds())
+
+    _, err := MatchBreeds(MatchRequest{})
+    assert.EqualError(t, err, "at least one preference is required")
+
+    _, err = MatchBreeds(MatchRequest{Grooming: &MatchPreference{Value: 9}})
+    assert.EqualError(t, err, "grooming must be between 1 and 5")
+
+    _, err = MatchBreeds(MatchRequest{Allergies: &MatchToggle{Enabled: true, Weight: -1}})
+    assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/server/web"
+    "math"
+    "math/rand"
+    "net/url"
+    "sort"
+)
+
+const (
+    personalStreamSize = 10
+
+    // A breed whose affinity drops to this level has been disliked
+    // repeatedly and is kept out of the exploration images too.
+    dislikeCutoff = -2.0
+)
+
+// voteAffinity is how much each vote moves a user's affinity for the
+// breeds of the voted image.
+var voteAffinity = map[string]float64{
+    VoteLove:    3,
+    VoteLike:    1,
+    VoteDislike: -1,
+}
+
+// BreedAffinity sums the user's votes per breed.
+func BreedAffinity(userID string) map[string]float64 {
+    affinity := make(map[string]float64)
+    for _, vote := range GetVotes(userID) {
+        for _, breedID := range vote.BreedIDs {
+            affinity[breedID] += voteAffinity[vote.Value]
+        }
+    }
+    return affinity
+}
+
+// FetchPersonalCats builds a voting stream biased towards the breeds the
+// user has liked. A share of the stream (personal_exploration_ratio) is
+// always random so the user keeps seeing new breeds; with no liked breeds
+// yet the whole stream is random.
+func FetchPersonalCats(userID string) ([]Cat, error) {
+    ratio := web.AppConfig.DefaultFloat("personal_exploration_ratio", 0.3)
+    ratio = math.Max(0, math.Min(1, ratio))
+
+    affinity := BreedAffinity(userID)
+    liked := make(map[string]float64)
+    for breedID, score := range affinity {
+        if score > 0 {
+            liked[breedID] = score
+        }
+    }
+    if len(liked) == 0 {
+        return FetchCats()
+    }
+
+    personalCount := int(math.Round(personalStreamSize * (1 - ratio)))
+    cats := []Cat{}
+    seen := make(map[string]bool)
+    for breedID, count := range sampleBreedCounts(liked, personalCount) {
+        breedCats, err := fetchCatsByBreed(breedID, count)
+        if err != nil {
+            return nil, err
+        }
+        for _, cat := range breedCats {
+            if !seen[cat.ID] {
+                seen[cat.ID] = true
+                cats = append(cats, cat)
+            }
+        }
+    }
+
+    if len(cats) < personalStreamSize {
+        random, err := FetchCats()
+        if err != nil {
+            return nil, err
+        }
+        for _, cat := range random {
+            if len(cats) == personalStreamSize {
+                break
+            }
+            if seen[cat.ID] || isDisliked(cat, affinity) {
+                continue
+            }
+            seen[cat.ID] = true
+            cats = append(cats, cat)
+        }
+    }
+
+    rand.Shuffle(len(cats), func(i, j int) {
+        cats[i], cats[j] = cats[j], cats[i]
+    })
+    rememberCats(cats)
+    return cats, nil
+}
+
+// sampleBreedCounts draws n breeds with probability proportional to their
+// weight and returns how many images to fetch for each.
+func sampleBreedCounts(weights map[string]float64, n int) map[string]int {
+    breedIDs := make([]string, 0, len(weights))
+    total := 0.0
+    for breedID, weight := range weights {
+        breedIDs = append(breedIDs, breedID)
+        total += weight
+    }
+    sort.Strings(breedIDs)
+
+    counts := make(map[string]int)
+    for i := 0; i < n; i++ {
+        pick := rand.Float64() * total
+        for _, breedID := range breedIDs {
+            pick -= weights[breedID]
+            if pick < 0 {
+                counts[breedID]++
+                break
+            }
+        }
+    }
+    return counts
+}
+
+func isDisliked(cat Cat, affinity map[string]float64) bool {
+    for _, breed := range cat.Breeds {
+        if affinity[breed.ID] <= dislikeCutoff {
+            return true
+        }
+    }
+    return false
+}
+
+func fetchCatsByBreed(breedID string, limit int) ([]Cat, error) {
+    catAPIURL, err := web.AppConfig.String("cat_api_url")
+    if err != nil {
+        return nil, err
+    }
+
+    searchURL := fmt.Sprintf("%s/images/search?breed_ids=%s&limit=%d", catAPIURL, url.QueryEscape(breedID), limit)
+    response, err := fetchFromAPI(searchURL)
+    if err != nil {
+        return nil, err
+    }
+
+    var cats []Cat
+    if err := json.Unmarshal(response, &cats); err != nil {
+        return nil, err
+    }
+    return cats, nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "strconv"
+    "testing"
+)
+
+func TestBreedAffinity(t *testing.T) {
+    resetVotes()
+    rememberCats([]Cat{
+        {ID: "a1", Breeds: []Breed{{ID: "abys"}}},
+        {ID: "b1", Breeds: []Breed{{ID: "beng"}}},
+    })
+    RecordVote("alice", VoteRequest{ImageID: "a1", Vote: VoteLove})
+    RecordVote("alice", VoteRequest{ImageID: "a1", Vote: VoteLike})
+    RecordVote("alice", VoteRequest{ImageID: "b1", Vote: VoteDislike})
+    RecordVote("bob", VoteRequest{ImageID: "b1", Vote: VoteLove})
+
+    affinity := BreedAffinity("alice")
+    assert.Equal(t, 4.0, affinity["abys"])
+    assert.Equal(t, -1.0, affinity["beng"])
+}
+
+func TestFetchPersonalCats(t *testing.T) {
+    resetVotes()
+    web.AppConfig.Set("personal_exploration_ratio", "0.3")
+
+    served := 0
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        breedID := r.URL.Query().Get("breed_ids")
+        limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+        body := "["
+        for i := 0; i < limit; i++ {
+            served++
+            if i > 0 {
+                body += ","
+            }
+            id := strconv.Itoa(served)
+            switch breedID {
+            case "":
+                // Random images: every other one is a disliked breed.
+                breed := "sibe"
+                if i%2 == 1 {
+                    breed = "beng"
+                }
+                body += `{"id":"r` + id + `","breeds":[{"id":"` + breed + `"}]}`
+            default:
+                body += `{"id":"p` + id + `","breeds":[{"id":"` + breedID + `"}]}`
+            }
+        }
+        w.Write([]byte(body + "]"))
+    })
+
+    // No votes yet: the stream is purely random.
+    cats, err := FetchPersonalCats("alice")
+    assert.NoError(t, err)
+    assert.Equal(t, 10, len(cats))
+
+    rememberCats([]Cat{
+        {ID: "a1", Breeds: []Breed{{ID: "abys"}}},
+        {ID: "b1", Breeds: []Breed{{ID: "beng"}}},
+    })
+    RecordVote("alice", VoteRequest{ImageID: "a1", Vote: VoteLove})
+    RecordVote("alice", VoteRequest{ImageID: "b1", Vote: VoteDislike})
+    RecordVote("alice", VoteRequest{ImageID: "b1", Vote: VoteDislike})
+
+    cats, err = FetchPersonalCats("alice")
+    assert.NoError(t, err)
+
+    counts := map[string]int{}
+    for _, cat := range cats {
+        counts[cat.Breeds[0].ID]++
+    }
+    assert.Equal(t, 7, counts["abys"])
+    assert.Equal(t, 3, counts["sibe"])
+    assert.Equal(t, 0, counts["beng"])
+}
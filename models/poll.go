@@ -0,0 +1,371 @@
+package models
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "sort"
+    "strconv"
+    "sync"
+    "time"
+)
+
+const (
+    pollsFile = "polls.json"
+    // pollVotesFile is an append-only log of pollVotes, so a vote doesn't
+    // rewrite the whole poll.
+    pollVotesFile = "poll_votes.log"
+
+    PollScheduled = "scheduled"
+    PollOpen      = "open"
+    PollClosed    = "closed"
+
+    maxPollImages = 20
+)
+
+type PollRequest struct {
+    Title          string    `json:"title"`
+    ImageIDs       []string  `json:"image_ids"`
+    BreedID        string    `json:"breed_id"`
+    OpensAt        time.Time `json:"opens_at"`
+    ClosesAt       time.Time `json:"closes_at"`
+    OneVotePerUser bool      `json:"one_vote_per_user"`
+}
+
+type Poll struct {
+    ID             string    `json:"id"`
+    Title          string    `json:"title"`
+    Images         []Cat     `json:"images"`
+    BreedID        string    `json:"breed_id,omitempty"`
+    OpensAt        time.Time `json:"opens_at"`
+    ClosesAt       time.Time `json:"closes_at"`
+    OneVotePerUser bool      `json:"one_vote_per_user"`
+    CreatedBy      string    `json:"created_by"`
+    CreatedAt      time.Time `json:"created_at"`
+    Status         string    `json:"status"`
+}
+
+type PollTally struct {
+    ImageID  string `json:"image_id"`
+    ImageURL string `json:"image_url"`
+    Likes    int    `json:"likes"`
+    Dislikes int    `json:"dislikes"`
+    Loves    int    `json:"loves"`
+    Score    int    `json:"score"`
+}
+
+type PollResults struct {
+    PollID     string      `json:"poll_id"`
+    Status     string      `json:"status"`
+    Final      bool        `json:"final"`
+    TotalVotes int         `json:"total_votes"`
+    Tallies    []PollTally `json:"tallies"`
+    Winners    []string    `json:"winners,omitempty"`
+}
+
+type pollVote struct {
+    PollID  string `json:"poll_id"`
+    UserID  string `json:"user_id"`
+    ImageID string `json:"image_id"`
+    Value   string `json:"value"`
+}
+
+// pollsState is everything persisted in pollsFile.
+type pollsState struct {
+    Polls []*Poll `json:"polls"`
+    Seq   int     `json:"seq"`
+}
+
+var (
+    polls       = make(map[string]*Poll)
+    pollVotes   = make(map[string][]pollVote)
+    pollSeq     int
+    pollsLoaded bool
+    pollMutex   sync.Mutex
+)
+
+// CreatePoll fixes the poll's image set up front, either from the curated
+// image IDs or from the breed filter, so every participant votes on the
+// same images.
+func CreatePoll(userID string, req PollRequest) (*Poll, error) {
+    if req.Title == "" {
+        return nil, fmt.Errorf("poll title is required")
+    }
+    if len(req.ImageIDs) == 0 && req.BreedID == "" {
+        return nil, fmt.Errorf("image_ids or breed_id is required")
+    }
+    if len(req.ImageIDs) > maxPollImages {
+        return nil, fmt.Errorf("a poll can have at most %d images", maxPollImages)
+    }
+    if req.OpensAt.IsZero() {
+        req.OpensAt = time.Now()
+    }
+    if req.ClosesAt.IsZero() || !req.ClosesAt.After(req.OpensAt) {
+        return nil, fmt.Errorf("closes_at must be after opens_at")
+    }
+
+    images := []Cat{}
+    if len(req.ImageIDs) > 0 {
+        seen := make(map[string]bool)
+        for _, id := range req.ImageIDs {
+            if seen[id] {
+                continue
+            }
+            seen[id] = true
+
+            cat, ok := LookupCat(id)
+            if !ok {
+                fetched, err := FetchImage(id)
+                if err != nil {
+                    return nil, err
+                }
+                cat = *fetched
+            }
+            images = append(images, cat)
+        }
+    } else {
+        cats, err := fetchCatsByBreed(req.BreedID, maxPollImages/2)
+        if err != nil {
+            return nil, err
+        }
+        if len(cats) == 0 {
+            return nil, fmt.Errorf("no images found for breed: %s", req.BreedID)
+        }
+        images = cats
+    }
+
+    pollMutex.Lock()
+    defer pollMutex.Unlock()
+
+    if err := loadPolls(); err != nil {
+        return nil, err
+    }
+    pollSeq++
+    poll := &Poll{
+        ID:             strconv.Itoa(pollSeq),
+        Title:          req.Title,
+        Images:         images,
+        BreedID:        req.BreedID,
+        OpensAt:        req.OpensAt,
+        ClosesAt:       req.ClosesAt,
+        OneVotePerUser: req.OneVotePerUser,
+        CreatedBy:      userID,
+        CreatedAt:      time.Now(),
+    }
+    polls[poll.ID] = poll
+    if err := savePolls(); err != nil {
+        delete(polls, poll.ID)
+        pollSeq--
+        return nil, err
+    }
+    return poll.withStatus(time.Now()), nil
+}
+
+// GetPoll returns a poll with its current status.
+func GetPoll(id string) (*Poll, error) {
+    pollMutex.Lock()
+    defer pollMutex.Unlock()
+
+    if err := loadPolls(); err != nil {
+        return nil, err
+    }
+    poll, ok := polls[id]
+    if !ok {
+        return nil, fmt.Errorf("poll not found: %s", id)
+    }
+    return poll.withStatus(time.Now()), nil
+}
+
+// GetPolls lists every poll, newest first.
+func GetPolls() []Poll {
+    pollMutex.Lock()
+    defer pollMutex.Unlock()
+
+    if err := loadPolls(); err != nil {
+        logs.Error("Error loading polls: %v", err)
+    }
+    now := time.Now()
+    result := make([]Poll, 0, len(polls))
+    for _, poll := range polls {
+        result = append(result, *poll.withStatus(now))
+    }
+    sort.Slice(result, func(i, j int) bool {
+        return result[i].CreatedAt.After(result[j].CreatedAt)
+    })
+    return result
+}
+
+// CastPollVote is the poll-scoped counterpart of a regular vote: it only
+// accepts votes on the poll's images while the poll is open. A user votes
+// at most once per image, or once in all if the poll says so.
+func CastPollVote(pollID string, userID string, req VoteRequest) error {
+    if err := req.Validate(); err != nil {
+        return err
+    }
+
+    pollMutex.Lock()
+    defer pollMutex.Unlock()
+
+    if err := loadPolls(); err != nil {
+        return err
+    }
+    poll, ok := polls[pollID]
+    if !ok {
+        return fmt.Errorf("poll not found: %s", pollID)
+    }
+    if status := poll.withStatus(time.Now()).Status; status != PollOpen {
+        return fmt.Errorf("poll is %s", status)
+    }
+    if !poll.hasImage(req.ImageID) {
+        return fmt.Errorf("image %s is not part of poll %s", req.ImageID, pollID)
+    }
+    for _, vote := range pollVotes[pollID] {
+        if vote.UserID != userID {
+            continue
+        }
+        if poll.OneVotePerUser {
+            return fmt.Errorf("already voted in this poll")
+        }
+        if vote.ImageID == req.ImageID {
+            return fmt.Errorf("already voted for image %s in this poll", req.ImageID)
+        }
+    }
+
+    vote := pollVote{
+        PollID:  pollID,
+        UserID:  userID,
+        ImageID: req.ImageID,
+        Value:   req.Vote,
+    }
+    if err := appendJSONLines(pollVotesFile, vote); err != nil {
+        return err
+    }
+    pollVotes[pollID] = append(pollVotes[pollID], vote)
+    return nil
+}
+
+// GetPollResults tallies the poll's votes. Results are live while the poll
+// is open and final once it has closed.
+func GetPollResults(pollID string) (*PollResults, error) {
+    pollMutex.Lock()
+    defer pollMutex.Unlock()
+
+    if err := loadPolls(); err != nil {
+        return nil, err
+    }
+    poll, ok := polls[pollID]
+    if !ok {
+        return nil, fmt.Errorf("poll not found: %s", pollID)
+    }
+    status := poll.withStatus(time.Now()).Status
+
+    tallies := make(map[string]*PollTally, len(poll.Images))
+    results := &PollResults{
+        PollID:  poll.ID,
+        Status:  status,
+        Final:   status == PollClosed,
+        Tallies: make([]PollTally, 0, len(poll.Images)),
+    }
+    for _, image := range poll.Images {
+        tallies[image.ID] = &PollTally{ImageID: image.ID, ImageURL: image.URL}
+    }
+
+    for _, vote := range pollVotes[pollID] {
+        tally := tallies[vote.ImageID]
+        switch vote.Value {
+        case VoteLike:
+            tally.Likes++
+            tally.Score++
+        case VoteLove:
+            tally.Loves++
+            tally.Score += 2
+        case VoteDislike:
+            tally.Dislikes++
+            tally.Score--
+        }
+        results.TotalVotes++
+    }
+
+    for _, image := range poll.Images {
+        results.Tallies = append(results.Tallies, *tallies[image.ID])
+    }
+    sort.SliceStable(results.Tallies, func(i, j int) bool {
+        return results.Tallies[i].Score > results.Tallies[j].Score
+    })
+
+    if results.Final && results.TotalVotes > 0 {
+        top := results.Tallies[0].Score
+        for _, tally := range results.Tallies {
+            if tally.Score == top {
+                results.Winners = append(results.Winners, tally.ImageID)
+            }
+        }
+    }
+    return results, nil
+}
+
+// loadPolls reads the polls and their votes on first use. Callers must
+// hold pollMutex.
+func loadPolls() error {
+    if pollsLoaded {
+        return nil
+    }
+    var state pollsState
+    if err := loadJSON(pollsFile, &state); err != nil {
+        return err
+    }
+    for _, poll := range state.Polls {
+        polls[poll.ID] = poll
+    }
+    if state.Seq > pollSeq {
+        pollSeq = state.Seq
+    }
+    err := loadJSONLines(pollVotesFile, func(line []byte) error {
+        var vote pollVote
+        if err := json.Unmarshal(line, &vote); err != nil {
+            return err
+        }
+        pollVotes[vote.PollID] = append(pollVotes[vote.PollID], vote)
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    pollsLoaded = true
+    return nil
+}
+
+// savePolls writes the polls; their votes are appended as they come in.
+// Callers must hold pollMutex.
+func savePolls() error {
+    state := pollsState{Polls: make([]*Poll, 0, len(polls)), Seq: pollSeq}
+    for _, poll := range polls {
+        state.Polls = append(state.Polls, poll)
+    }
+    sort.Slice(state.Polls, func(i, j int) bool {
+        return state.Polls[i].CreatedAt.Before(state.Polls[j].CreatedAt)
+    })
+    return saveJSON(pollsFile, state)
+}
+
+func (p *Poll) withStatus(now time.Time) *Poll {
+    poll := *p
+    switch {
+    case now.Before(p.OpensAt):
+        poll.Status = PollScheduled
+    case now.Before(p.ClosesAt):
+        poll.Status = PollOpen
+    default:
+        poll.Status = PollClosed
+    }
+    return &poll
+}
+
+func (p *Poll) hasImage(imageID string) bool {
+    for _, image := range p.Images {
+        if image.ID == imageID {
+            return true
+        }
+    }
+    return false
+}
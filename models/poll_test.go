@@ -0,0 +1,136 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func resetPolls() {
+    pollMutex.Lock()
+    polls = make(map[string]*Poll)
+    pollVotes = make(map[string][]pollVote)
+    pollsLoaded = true
+    pollMutex.Unlock()
+}
+
+func TestPollLifecycle(t *testing.T) {
+    resetPolls()
+    rememberCats([]Cat{
+        {ID: "img1", URL: "http://example.com/1.jpg"},
+        {ID: "img2", URL: "http://example.com/2.jpg"},
+    })
+
+    poll, err := CreatePoll("alice", PollRequest{
+        Title:          "Cat of the sprint",
+        ImageIDs:       []string{"img1", "img2", "img1"},
+        ClosesAt:       time.Now().Add(time.Hour),
+        OneVotePerUser: true,
+    })
+    assert.NoError(t, err)
+    assert.Equal(t, PollOpen, poll.Status)
+    assert.Equal(t, 2, len(poll.Images))
+
+    assert.NoError(t, CastPollVote(poll.ID, "alice", VoteRequest{ImageID: "img2", Vote: VoteLove}))
+    assert.NoError(t, CastPollVote(poll.ID, "bob", VoteRequest{ImageID: "img1", Vote: VoteLike}))
+    assert.EqualError(t, CastPollVote(poll.ID, "bob", VoteRequest{ImageID: "img2", Vote: VoteLike}), "already voted in this poll")
+    assert.Error(t, CastPollVote(poll.ID, "carol", VoteRequest{ImageID: "img9", Vote: VoteLike}))
+
+    results, err := GetPollResults(poll.ID)
+    assert.NoError(t, err)
+    assert.False(t, results.Final)
+    assert.Equal(t, 2, results.TotalVotes)
+    assert.Equal(t, "img2", results.Tallies[0].ImageID)
+    assert.Empty(t, results.Winners)
+
+    // Close the poll: results become final and voting stops.
+    pollMutex.Lock()
+    polls[poll.ID].ClosesAt = time.Now().Add(-time.Minute)
+    pollMutex.Unlock()
+
+    results, err = GetPollResults(poll.ID)
+    assert.NoError(t, err)
+    assert.True(t, results.Final)
+    assert.Equal(t, []string{"img2"}, results.Winners)
+    assert.EqualError(t, CastPollVote(poll.ID, "dave", VoteRequest{ImageID: "img1", Vote: VoteLike}), "poll is closed")
+}
+
+func TestPollsSurviveRestart(t *testing.T) {
+    withDataDir(t)
+    resetPolls()
+    rememberCats([]Cat{
+        {ID: "img1", URL: "http://example.com/1.jpg"},
+        {ID: "img2", URL: "http://example.com/2.jpg"},
+    })
+
+    poll, err := CreatePoll("alice", PollRequest{
+        Title:    "Open vote",
+        ImageIDs: []string{"img1", "img2"},
+        ClosesAt: time.Now().Add(time.Hour),
+    })
+    assert.NoError(t, err)
+    assert.NoError(t, CastPollVote(poll.ID, "bob", VoteRequest{ImageID: "img1", Vote: VoteLike}))
+    assert.NoError(t, CastPollVote(poll.ID, "bob", VoteRequest{ImageID: "img2", Vote: VoteLove}))
+    assert.Error(t, CastPollVote(poll.ID, "bob", VoteRequest{ImageID: "img1", Vote: VoteLove}), "one vote per image")
+
+    pollMutex.Lock()
+    polls, pollVotes, pollsLoaded = make(map[string]*Poll), make(map[string][]pollVote), false
+    pollMutex.Unlock()
+
+    restored, err := GetPoll(poll.ID)
+    assert.NoError(t, err)
+    assert.Equal(t, "Open vote", restored.Title)
+    results, err := GetPollResults(poll.ID)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, results.TotalVotes)
+    assert.Equal(t, "img2", results.Tallies[0].ImageID)
+    assert.Error(t, CastPollVote(poll.ID, "bob", VoteRequest{ImageID: "img2", Vote: VoteLike}))
+}
+
+func TestCreatePollFromBreed(t *testing.T) {
+    resetPolls()
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        assert.Equal(t, "beng", r.URL.Query().Get("breed_ids"))
+        w.Write([]byte(`[{"id":"b1","url":"http://example.com/b1.jpg"},{"id":"b2","url":"http://example.com/b2.jpg"}]`))
+    })
+
+    poll, err := CreatePoll("alice", PollRequest{
+        Title:    "Best Bengal",
+        BreedID:  "beng",
+        OpensAt:  time.Now().Add(time.Hour),
+        ClosesAt: time.Now().Add(2 * time.Hour),
+    })
+    assert.NoError(t, err)
+    assert.Equal(t, PollScheduled, poll.Status)
+    assert.Equal(t, 2, len(poll.Images))
+    assert.EqualError(t, CastPollVote(poll.ID, "bob", VoteRequest{ImageID: "b1", Vote: VoteLike}), "poll is scheduled")
+}
+
+func TestFetchImageEscapesID(t *testing.T) {
+    var requested string
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        requested = r.URL.EscapedPath() + "?" + r.URL.RawQuery
+        w.Write([]byte(`{"id":"x","url":"http://example.com/x.jpg"}`))
+    })
+
+    // An image ID can't steer the request to another endpoint.
+    FetchImage("../votes?x=")
+    assert.Equal(t, "/images/..%2Fvotes%3Fx=?", requested)
+
+    fetchCatsByBreed("abys&limit=100", 5)
+    assert.Equal(t, "/images/search?breed_ids=abys%26limit%3D100&limit=5", requested)
+}
+
+func TestCreatePollValidation(t *testing.T) {
+    resetPolls()
+
+    _, err := CreatePoll("alice", PollRequest{ImageIDs: []string{"img1"}, ClosesAt: time.Now().Add(time.Hour)})
+    assert.EqualError(t, err, "poll title is required")
+
+    _, err = CreatePoll("alice", PollRequest{Title: "t", ClosesAt: time.Now().Add(time.Hour)})
+    assert.EqualError(t, err, "image_ids or breed_id is required")
+
+    _, err = CreatePoll("alice", PollRequest{Title: "t", ImageIDs: []string{"img1"}})
+    assert.EqualError(t, err, "closes_at must be after opens_at")
+}
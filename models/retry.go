@@ -0,0 +1,26 @@
+package models
+
+import (
+    "github.com/beego/beego/v2/server/web"
+    "time"
+)
+
+const maxRetryBackoff = time.Hour
+
+// retryBackoff is how long to wait after a delivery has failed attempts
+// times: the duration configured under key (or fallback), doubled after
+// every further failure, up to an hour.
+func retryBackoff(key string, fallback time.Duration, attempts int) time.Duration {
+    base, err := time.ParseDuration(web.AppConfig.DefaultString(key, fallback.String()))
+    if err != nil || base <= 0 {
+        base = fallback
+    }
+    backoff := base
+    for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+        backoff *= 2
+    }
+    if backoff > maxRetryBackoff {
+        backoff = maxRetryBackoff
+    }
+    return backoff
+}
@@ -0,0 +1,244 @@
+package models
+
+import (
+    "html"
+    "math"
+    "sort"
+    "strings"
+    "sync"
+    "unicode"
+)
+
+// Field boosts used when indexing a breed; a hit in the name matters more
+// than the same word buried in the description.
+const (
+    nameBoost        = 3.0
+    temperamentBoost = 2.0
+    originBoost      = 1.5
+    descriptionBoost = 1.0
+
+    snippetWords = 24
+)
+
+type SearchHit struct {
+    ID      string   `json:"id"`
+    Name    string   `json:"name"`
+    Origin  string   `json:"origin"`
+    Score   float64  `json:"score"`
+    Matched []string `json:"matched_terms"`
+    Snippet string   `json:"snippet"`
+}
+
+type searchIndex struct {
+    postings map[string]map[string]float64 // term -> breed ID -> weighted term frequency
+    breeds   map[string]Breed
+}
+
+var (
+    breedIndex *searchIndex
+    indexMutex sync.RWMutex
+)
+
+var stopWords = map[string]bool{
+    "a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+    "be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+    "have": true, "in": true, "is": true, "it": true, "its": true, "of": true,
+    "on": true, "or": true, "that": true, "the": true, "their": true, "they": true,
+    "this": true, "to": true, "very": true, "was": true, "which": true, "with": true,
+}
+
+// SearchBreeds ranks catalogue breeds against a free-text query using the
+// inverted index built on the last catalogue refresh.
+func SearchBreeds(query string, limit int) ([]SearchHit, error) {
+    indexMutex.RLock()
+    idx := breedIndex
+    indexMutex.RUnlock()
+
+    if idx == nil {
+        if _, err := GetBreedCatalog(); err != nil {
+            return nil, err
+        }
+        indexMutex.RLock()
+        idx = breedIndex
+        indexMutex.RUnlock()
+    }
+
+    terms := uniqueTerms(tokenize(query))
+    hits := []SearchHit{}
+    if idx == nil || len(terms) == 0 {
+        return hits, nil
+    }
+
+    scores := make(map[string]float64)
+    matched := make(map[string][]string)
+    docCount := float64(len(idx.breeds))
+    for _, term := range terms {
+        postings := idx.postings[term]
+        if len(postings) == 0 {
+            continue
+        }
+        idf := math.Log(1 + docCount/float64(len(postings)))
+        for breedID, weight := range postings {
+            scores[breedID] += weight * idf
+            matched[breedID] = append(matched[breedID], term)
+        }
+    }
+
+    for breedID, score := range scores {
+        breed := idx.breeds[breedID]
+        // Reward breeds that cover more of the query, so "playful indoor
+        // hypoallergenic" prefers breeds matching all three words.
+        coverage := float64(len(matched[breedID])) / float64(len(terms))
+        hits = append(hits, SearchHit{
+            ID:      breed.ID,
+            Name:    breed.Name,
+            Origin:  breed.Origin,
+            Score:   math.Round(score*coverage*1000) / 1000,
+            Matched: matched[breedID],
+            Snippet: buildSnippet(breed, matched[breedID]),
+        })
+    }
+
+    sort.Slice(hits, func(i, j int) bool {
+        if hits[i].Score != hits[j].Score {
+            return hits[i].Score > hits[j].Score
+        }
+        return hits[i].Name < hits[j].Name
+    })
+
+    if limit > 0 && len(hits) > limit {
+        hits = hits[:limit]
+    }
+    return hits, nil
+}
+
+func rebuildSearchIndex(breeds []Breed) {
+    idx := &searchIndex{
+        postings: make(map[string]map[string]float64),
+        breeds:   make(map[string]Breed, len(breeds)),
+    }
+
+    for _, breed := range breeds {
+        idx.breeds[breed.ID] = breed
+        idx.add(breed.ID, breed.Name, nameBoost)
+        idx.add(breed.ID, breed.Temperament, temperamentBoost)
+        idx.add(breed.ID, breed.Origin, originBoost)
+        idx.add(breed.ID, breed.Description, descriptionBoost)
+
+        // The catalogue exposes these as 0/1 flags rather than prose, so
+        // index them as words to make them searchable.
+        if breed.Indoor > 0 {
+            idx.add(breed.ID, "indoor", temperamentBoost)
+        }
+        if breed.Hypoallergenic > 0 {
+            idx.add(breed.ID, "hypoallergenic", temperamentBoost)
+        }
+    }
+
+    indexMutex.Lock()
+    breedIndex = idx
+    indexMutex.Unlock()
+}
+
+func (idx *searchIndex) add(breedID string, text string, boost float64) {
+    for _, term := range tokenize(text) {
+        if idx.postings[term] == nil {
+            idx.postings[term] = make(map[string]float64)
+        }
+        idx.postings[term][breedID] += boost
+    }
+}
+
+// tokenize lowercases text, splits it into words, drops stop words and
+// applies a light plural stem so "cats" and "cat" index together.
+func tokenize(text string) []string {
+    words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+
+    terms := make([]string, 0, len(words))
+    for _, word := range words {
+        if stopWords[word] {
+            continue
+        }
+        terms = append(terms, stem(word))
+    }
+    return terms
+}
+
+func stem(word string) string {
+    if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+        return strings.TrimSuffix(word, "s")
+    }
+    return word
+}
+
+func uniqueTerms(terms []string) []string {
+    seen := make(map[string]bool, len(terms))
+    unique := terms[:0]
+    for _, term := range terms {
+        if !seen[term] {
+            seen[term] = true
+            unique = append(unique, term)
+        }
+    }
+    return unique
+}
+
+// buildSnippet returns an HTML-escaped excerpt of the breed description (or
+// temperament) around the first matched term, with matches wrapped in <mark>.
+func buildSnippet(breed Breed, matched []string) string {
+    terms := make(map[string]bool, len(matched))
+    for _, term := range matched {
+        terms[term] = true
+    }
+
+    for _, text := range []string{breed.Description, breed.Temperament} {
+        words := strings.Fields(text)
+        for i, word := range words {
+            if wordMatches(word, terms) {
+                start := i - snippetWords/3
+                if start < 0 {
+                    start = 0
+                }
+                return highlightWords(words, start, terms)
+            }
+        }
+    }
+
+    return highlightWords(strings.Fields(breed.Description), 0, terms)
+}
+
+func highlightWords(words []string, start int, terms map[string]bool) string {
+    end := start + snippetWords
+    if end > len(words) {
+        end = len(words)
+    }
+
+    parts := make([]string, 0, end-start)
+    for _, word := range words[start:end] {
+        escaped := html.EscapeString(word)
+        if wordMatches(word, terms) {
+            escaped = "<mark>" + escaped + "</mark>"
+        }
+        parts = append(parts, escaped)
+    }
+
+    snippet := strings.Join(parts, " ")
+    if start > 0 {
+        snippet = "…" + snippet
+    }
+    if end < len(words) {
+        snippet += "…"
+    }
+    return snippet
+}
+
+func wordMatches(word string, terms map[string]bool) bool {
+    for _, term := range tokenize(word) {
+        if terms[term] {
+            return true
+        }
+    }
+    return false
+}
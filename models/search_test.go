@@ -0,0 +1,81 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "strings"
+    "testing"
+)
+
+func sampleBreeds() []Breed {
+    return []Breed{
+        {
+            ID:          "abys",
+            Name:        "Abyssinian",
+            Origin:      "Egypt",
+            Temperament: "Active, Energetic, Independent, Intelligent, Gentle",
+            Description: "The Abyssinian is easy to care for, and a joy to have in your home. They're affectionate cats and love both people and other animals.",
+        },
+        {
+            ID:             "bali",
+            Name:           "Balinese",
+            Origin:         "United States",
+            Temperament:    "Affectionate, Intelligent, Playful",
+            Description:    "Balinese are curious, outgoing, intelligent cats with excellent communication skills.",
+            Hypoallergenic: 1,
+        },
+        {
+            ID:          "bomb",
+            Name:        "Bombay",
+            Origin:      "United States",
+            Temperament: "Affectionate, Dependent, Gentle, Intelligent, Playful",
+            Description: "The golden eyes and the shiny black coat of the Bombay is absolutely striking.",
+            Indoor:      1,
+        },
+        {
+            ID:             "sibe",
+            Name:           "Siberian",
+            Origin:         "Russia",
+            Temperament:    "Curious, Intelligent, Loyal, Sweet, Agile, Playful, Affectionate",
+            Description:    "The Siberians dog like temperament and affection makes the ideal lap cat and will live quite happily indoors.",
+            Indoor:         1,
+            Hypoallergenic: 1,
+        },
+    }
+}
+
+func TestSearchBreedsRanking(t *testing.T) {
+    SetBreedCatalog(sampleBreeds())
+
+    hits, err := SearchBreeds("playful indoor hypoallergenic", 10)
+    assert.NoError(t, err)
+    assert.Equal(t, 3, len(hits))
+    assert.Equal(t, "sibe", hits[0].ID)
+    assert.ElementsMatch(t, []string{"playful", "indoor", "hypoallergenic"}, hits[0].Matched)
+
+    // A hit in the name outranks the same word in another breed's description.
+    hits, err = SearchBreeds("Bombay", 10)
+    assert.NoError(t, err)
+    assert.Equal(t, "bomb", hits[0].ID)
+
+    hits, err = SearchBreeds("the and", 10)
+    assert.NoError(t, err)
+    assert.Empty(t, hits)
+}
+
+func TestSearchBreedsLimitAndSnippet(t *testing.T) {
+    SetBreedCatalog(sampleBreeds())
+
+    hits, err := SearchBreeds("intelligent", 2)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, len(hits))
+
+    hits, err = SearchBreeds("curious", 10)
+    assert.NoError(t, err)
+    for _, hit := range hits {
+        assert.True(t, strings.Contains(hit.Snippet, "<mark>"), hit.Snippet)
+    }
+}
+
+func TestTokenize(t *testing.T) {
+    assert.Equal(t, []string{"playful", "cat", "love", "people"}, tokenize("The playful cats love PEOPLE!"))
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+    "crypto/rand"
+    "encoding/base64"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    sharesFile = "shares.json"
+
+    defaultShareTitle = "Favorite cats"
+    maxShareTitle     = 100
+
+    // shareViewsSaveInterval is the least time between saves of the view
+    // counts, so public traffic doesn't turn into a disk write per view.
+    shareViewsSaveInterval = time.Minute
+)
+
+// ShareRequest publishes a collection, or every favorite when Collection is
+// empty. ExpiresAt is optional.
+type ShareRequest struct {
+    Collection string     `json:"collection"`
+    Title      string     `json:"title"`
+    ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// Share is a public, read-only link to favorites. The token is the only
+// thing protecting it, so it is long and random. UserID is the creator's
+// session and is stripped from everything returned.
+type Share struct {
+    Token        string     `json:"token"`
+    UserID       string     `json:"user_id,omitempty"`
+    Collection   string     `json:"collection,omitempty"`
+    Title        string     `json:"title"`
+    Views        int        `json:"views"`
+    CreatedAt    time.Time  `json:"created_at"`
+    ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+    RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+    LastViewedAt *time.Time `json:"last_viewed_at,omitempty"`
+}
+
+// SharedFavorites is what a share page shows. Notes are left out.
+type SharedFavorites struct {
+    Share  Share           `json:"share"`
+    Images []FavoriteImage `json:"images"`
+}
+
+var (
+    shares       []Share
+    sharesLoaded bool
+    shareMutex   sync.Mutex
+
+    // shareViewsDirty is set while counted views haven't been saved yet.
+    shareViewsDirty   bool
+    shareViewsSavedAt time.Time
+)
+
+// CreateShare publishes favorites under a new token.
+func CreateShare(userID string, req ShareRequest) (*Share, error) {
+    title := strings.TrimSpace(req.Title)
+    if len(title) > maxShareTitle {
+        return nil, fmt.Errorf("title must be at most %d characters", maxShareTitle)
+    }
+    if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+        return nil, fmt.Errorf("expires_at must be in the future")
+    }
+    if req.Collection == DefaultCollection {
+        req.Collection = ""
+    }
+    if req.Collection != "" {
+        name, ok := collectionName(req.Collection)
+        if !ok {
+            return nil, fmt.Errorf("collection not found: %s", req.Collection)
+        }
+        if title == "" {
+            title = name
+        }
+    }
+    if title == "" {
+        title = defaultShareTitle
+    }
+
+    buf := make([]byte, 32)
+    if _, err := rand.Read(buf); err != nil {
+        return nil, err
+    }
+
+    shareMutex.Lock()
+    defer shareMutex.Unlock()
+
+    if err := loadShares(); err != nil {
+        return nil, err
+    }
+
+    share := Share{
+        Token:      base64.RawURLEncoding.EncodeToString(buf),
+        UserID:     userID,
+        Collection: req.Collection,
+        Title:      title,
+        CreatedAt:  time.Now(),
+        ExpiresAt:  req.ExpiresAt,
+    }
+    shares = append(shares, share)
+    if err := saveJSON(sharesFile, shares); err != nil {
+        shares = shares[:len(shares)-1]
+        return nil, err
+    }
+    return share.public(), nil
+}
+
+// GetShares lists the shares userID created, newest first, revoked and
+// expired ones included.
+func GetShares(userID string) ([]Share, error) {
+    shareMutex.Lock()
+    defer shareMutex.Unlock()
+
+    if err := loadShares(); err != nil {
+        return nil, err
+    }
+
+    result := []Share{}
+    for i := len(shares) - 1; i >= 0; i-- {
+        if shares[i].UserID == userID {
+            result = append(result, *shares[i].public())
+        }
+    }
+    return result, nil
+}
+
+// RevokeShare takes a share down for good. Only its creator can revoke it.
+func RevokeShare(userID string, token string) (*Share, error) {
+    shareMutex.Lock()
+    defer shareMutex.Unlock()
+
+    if err := loadShares(); err != nil {
+        return nil, err
+    }
+
+    for i := range shares {
+        if shares[i].Token != token || shares[i].UserID != userID {
+            continue
+        }
+        if shares[i].RevokedAt == nil {
+            now := time.Now()
+            shares[i].RevokedAt = &now
+            if err := saveJSON(sharesFile, shares); err != nil {
+                shares[i].RevokedAt = nil
+                return nil, err
+            }
+        }
+        return shares[i].public(), nil
+    }
+    return nil, fmt.Errorf("share not found: %s", token)
+}
+
+// ViewShare returns the favorites behind a token and counts the view. The
+// counts are saved at most every shareViewsSaveInterval; FlushShareViews
+// saves the rest. Unknown, revoked and expired tokens get the same error so
+// a visitor can't tell them apart.
+func ViewShare(token string, now time.Time) (*SharedFavorites, error) {
+    shareMutex.Lock()
+    defer shareMutex.Unlock()
+
+    if err := loadShares(); err != nil {
+        return nil, err
+    }
+
+    for i := range shares {
+        share := &shares[i]
+        if share.Token != token {
+            continue
+        }
+        if share.RevokedAt != nil || (share.ExpiresAt != nil && !share.ExpiresAt.After(now)) {
+            break
+        }
+
+        images, err := QueryFavorites(FavoriteQuery{Collection: share.Collection})
+        if err != nil {
+            // The shared collection has been deleted.
+            break
+        }
+
+        // Notes are private; only the images and their tags are shared.
+        for j := range images {
+            images[j].Notes = ""
+        }
+
+        share.Views++
+        share.LastViewedAt = &now
+        shareViewsDirty = true
+        if time.Since(shareViewsSavedAt) >= shareViewsSaveInterval {
+            if err := saveShareViews(); err != nil {
+                logs.Error("Error saving share views: %v", err)
+            }
+        }
+        return &SharedFavorites{Share: *share.public(), Images: images}, nil
+    }
+    return nil, fmt.Errorf("this share doesn't exist or is no longer available")
+}
+
+// FlushShareViews saves view counts that ViewShare hasn't saved yet.
+func FlushShareViews() error {
+    shareMutex.Lock()
+    defer shareMutex.Unlock()
+
+    if !shareViewsDirty {
+        return nil
+    }
+    return saveShareViews()
+}
+
+// saveShareViews saves the shares with their view counts. Callers must
+// hold shareMutex.
+func saveShareViews() error {
+    if err := saveJSON(sharesFile, shares); err != nil {
+        return err
+    }
+    shareViewsDirty = false
+    shareViewsSavedAt = time.Now()
+    return nil
+}
+
+// public returns a copy of the share that is safe to send to clients.
+func (s Share) public() *Share {
+    s.UserID = ""
+    return &s
+}
+
+func collectionName(id string) (string, bool) {
+    for _, collection := range GetCollections() {
+        if collection.ID == id {
+            return collection.Name, true
+        }
+    }
+    return "", false
+}
+
+// loadShares reads the persisted shares on first use. Callers must hold
+// shareMutex.
+func loadShares() error {
+    if sharesLoaded {
+        return nil
+    }
+    if err := loadJSON(sharesFile, &shares); err != nil {
+        return err
+    }
+    sharesLoaded = true
+    return nil
+}
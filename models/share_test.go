@@ -0,0 +1,109 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func resetShares() {
+    shareMutex.Lock()
+    shares = nil
+    sharesLoaded = true
+    shareViewsDirty, shareViewsSavedAt = false, time.Time{}
+    shareMutex.Unlock()
+}
+
+func TestShareFavorites(t *testing.T) {
+    resetCollections()
+    resetShares()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+    notes := "my secret"
+    UpdateFavorite("a", FavoriteUpdate{Notes: &notes})
+    desk, _ := CreateCollection("Desk")
+    AddToCollection(desk.ID, "b", "")
+
+    all, err := CreateShare("alice", ShareRequest{})
+    assert.NoError(t, err)
+    assert.Equal(t, defaultShareTitle, all.Title)
+    assert.Empty(t, all.UserID, "the owner is never sent to clients")
+    assert.True(t, len(all.Token) >= 43)
+
+    shared, err := ViewShare(all.Token, time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"a", "b"}, favoriteIDs(shared.Images))
+    assert.Empty(t, shared.Images[0].Notes)
+    assert.Empty(t, shared.Share.UserID)
+
+    deskShare, err := CreateShare("alice", ShareRequest{Collection: desk.ID})
+    assert.NoError(t, err)
+    assert.Equal(t, "Desk", deskShare.Title)
+    shared, _ = ViewShare(deskShare.Token, time.Now())
+    assert.Equal(t, []string{"b"}, favoriteIDs(shared.Images))
+
+    _, err = CreateShare("alice", ShareRequest{Collection: "missing"})
+    assert.Error(t, err)
+    past := time.Now().Add(-time.Hour)
+    _, err = CreateShare("alice", ShareRequest{ExpiresAt: &past})
+    assert.Error(t, err)
+
+    mine, _ := GetShares("alice")
+    assert.Equal(t, 2, len(mine))
+    assert.Equal(t, deskShare.Token, mine[0].Token, "newest first")
+    assert.Equal(t, 1, mine[1].Views)
+    theirs, _ := GetShares("bob")
+    assert.Empty(t, theirs)
+
+    // Deleting the collection takes its share down too.
+    DeleteCollection(desk.ID)
+    _, err = ViewShare(deskShare.Token, time.Now())
+    assert.Error(t, err)
+}
+
+func TestRevokeAndExpireShare(t *testing.T) {
+    resetCollections()
+    resetShares()
+    SaveFavorite("a", "http://example.com/a.jpg")
+
+    share, _ := CreateShare("alice", ShareRequest{})
+    _, err := RevokeShare("bob", share.Token)
+    assert.Error(t, err, "only the creator can revoke a share")
+    revoked, err := RevokeShare("alice", share.Token)
+    assert.NoError(t, err)
+    assert.NotNil(t, revoked.RevokedAt)
+    _, err = ViewShare(share.Token, time.Now())
+    assert.Error(t, err)
+
+    expires := time.Now().Add(time.Hour)
+    share, _ = CreateShare("alice", ShareRequest{ExpiresAt: &expires})
+    _, err = ViewShare(share.Token, time.Now())
+    assert.NoError(t, err)
+    _, err = ViewShare(share.Token, expires)
+    assert.Error(t, err)
+
+    _, err = ViewShare("unknown", time.Now())
+    assert.Error(t, err)
+}
+
+func TestShareViewsAreSavedInBatches(t *testing.T) {
+    withDataDir(t)
+    resetCollections()
+    resetShares()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    share, _ := CreateShare("alice", ShareRequest{})
+
+    savedViews := func() int {
+        var stored []Share
+        loadJSON(sharesFile, &stored)
+        return stored[0].Views
+    }
+    for i := 0; i < 3; i++ {
+        _, err := ViewShare(share.Token, time.Now())
+        assert.NoError(t, err)
+    }
+    assert.Equal(t, 1, savedViews(), "views right after a save wait for the next one")
+
+    assert.NoError(t, FlushShareViews())
+    assert.Equal(t, 3, savedViews())
+}
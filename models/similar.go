@@ -0,0 +1,155 @@
+package models
+
+import (
+    "fmt"
+    "math"
+    "sort"
+)
+
+const (
+    maxSharedTraits = 3
+
+    // Breeds scored on fewer common traits than this aren't compared: a
+    // couple of matching scores would make them look identical.
+    minComparedTraits = 6
+)
+
+type SharedTrait struct {
+    Key        string `json:"key"`
+    Label      string `json:"label"`
+    Score      int    `json:"score"`
+    OtherScore int    `json:"other_score"`
+}
+
+type SimilarBreed struct {
+    ID           string        `json:"id"`
+    Name         string        `json:"name"`
+    Similarity   float64       `json:"similarity"`
+    SharedTraits []SharedTrait `json:"shared_traits"`
+}
+
+// SimilarBreeds returns the n catalogue breeds whose trait vectors are
+// closest to the given breed. Similarity is 1 minus the Euclidean distance
+// between the vectors, scaled to the largest possible distance, so 1 means
+// identical scores.
+func SimilarBreeds(breedID string, n int) ([]SimilarBreed, error) {
+    breeds, err := GetBreedCatalog()
+    if err != nil {
+        return nil, err
+    }
+
+    var target *Breed
+    for i := range breeds {
+        if breeds[i].ID == breedID {
+            target = &breeds[i]
+            break
+        }
+    }
+    if target == nil {
+        return nil, fmt.Errorf("breed not found: %s", breedID)
+    }
+
+    targetScores := TraitScores(*target)
+    similar := []SimilarBreed{}
+    for _, breed := range breeds {
+        if breed.ID == target.ID {
+            continue
+        }
+        similarity, ok := traitSimilarity(targetScores, TraitScores(breed))
+        if !ok {
+            continue
+        }
+        similar = append(similar, SimilarBreed{
+            ID:           breed.ID,
+            Name:         breed.Name,
+            Similarity:   math.Round(similarity*1000) / 1000,
+            SharedTraits: sharedTraits(targetScores, TraitScores(breed)),
+        })
+    }
+
+    sort.Slice(similar, func(i, j int) bool {
+        if similar[i].Similarity != similar[j].Similarity {
+            return similar[i].Similarity > similar[j].Similarity
+        }
+        return similar[i].Name < similar[j].Name
+    })
+
+    if n > 0 && len(similar) > n {
+        similar = similar[:n]
+    }
+    return similar, nil
+}
+
+// traitSimilarity compares two trait vectors, skipping traits either breed
+// has no score for. It reports false when the breeds share fewer than
+// minComparedTraits scored traits.
+func traitSimilarity(a, b map[string]int) (float64, bool) {
+    sum := 0.0
+    compared := 0
+    for _, trait := range breedTraits {
+        if a[trait.Key] == 0 || b[trait.Key] == 0 {
+            continue
+        }
+        diff := float64(a[trait.Key] - b[trait.Key])
+        sum += diff * diff
+        compared++
+    }
+    if compared < minComparedTraits {
+        return 0, false
+    }
+
+    // Scores range 1–5, so each trait differs by at most 4.
+    maxDistance := math.Sqrt(float64(compared) * 16)
+    return 1 - math.Sqrt(sum)/maxDistance, true
+}
+
+// sharedTraits picks the traits that drive the similarity: those where the
+// scores are closest, preferring distinctive ones far from the middle score.
+func sharedTraits(a, b map[string]int) []SharedTrait {
+    type candidate struct {
+        trait           Trait
+        diff            int
+        distinctiveness float64
+    }
+
+    candidates := []candidate{}
+    for _, trait := range breedTraits {
+        x, y := a[trait.Key], b[trait.Key]
+        if x == 0 || y == 0 {
+            continue
+        }
+        diff := x - y
+        if diff < 0 {
+            diff = -diff
+        }
+        if diff > 1 {
+            continue
+        }
+        candidates = append(candidates, candidate{
+            trait:           trait,
+            diff:            diff,
+            distinctiveness: math.Abs(float64(x+y)/2 - 3),
+        })
+    }
+
+    sort.SliceStable(candidates, func(i, j int) bool {
+        if candidates[i].diff != candidates[j].diff {
+            return candidates[i].diff < candidates[j].diff
+        }
+        return candidates[i].distinctiveness > candidates[j].distinctiveness
+    })
+
+    shared := []SharedTrait{}
+    for _, c := range candidates {
+        if len(shared) == maxSharedTraits {
+            break
+        }
+        shared = append(shared, SharedTrait{
+            Key:        c.trait.Key,
+            Label:      c.trait.Label,
+            Score:      a[c.trait.Key],
+            OtherScore: b[c.trait.Key],
+        })
+    }
+    return shared
+}
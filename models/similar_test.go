@@ -0,0 +1,58 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+)
+
+func traitBreed(id string, scores ...int) Breed {
+    return Breed{
+        ID: id, Name: id,
+        Adaptability: scores[0], AffectionLevel: scores[1], ChildFriendly: scores[2],
+        DogFriendly: scores[3], EnergyLevel: scores[4], Grooming: scores[5],
+        HealthIssues: scores[6], Intelligence: scores[7], SheddingLevel: scores[8],
+        SocialNeeds: scores[9], StrangerFriendly: scores[10], Vocalisation: scores[11],
+    }
+}
+
+func TestSimilarBreeds(t *testing.T) {
+    SetBreedCatalog([]Breed{
+        traitBreed("abys", 5, 5, 3, 4, 5, 1, 2, 5, 2, 5, 5, 1),
+        traitBreed("beng", 5, 5, 4, 5, 5, 1, 3, 5, 3, 5, 3, 5),
+        traitBreed("pers", 5, 5, 2, 2, 1, 5, 3, 3, 5, 4, 3, 1),
+        traitBreed("none", 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0),
+    })
+
+    similar, err := SimilarBreeds("abys", 5)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, len(similar))
+    assert.Equal(t, "beng", similar[0].ID)
+    assert.True(t, similar[0].Similarity > similar[1].Similarity)
+    assert.Equal(t, maxSharedTraits, len(similar[0].SharedTraits))
+    assert.Equal(t, 5, similar[0].SharedTraits[0].Score)
+
+    similar, err = SimilarBreeds("abys", 1)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(similar))
+
+    _, err = SimilarBreeds("missing", 5)
+    assert.Error(t, err)
+}
+
+func TestTraitSimilarity(t *testing.T) {
+    a := TraitScores(traitBreed("a", 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1))
+    b := TraitScores(traitBreed("b", 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5))
+
+    similarity, ok := traitSimilarity(a, a)
+    assert.True(t, ok)
+    assert.Equal(t, 1.0, similarity)
+
+    similarity, ok = traitSimilarity(a, b)
+    assert.True(t, ok)
+    assert.Equal(t, 0.0, similarity)
+
+    // Two shared traits aren't enough to call breeds alike.
+    sparse := TraitScores(traitBreed("c", 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0))
+    _, ok = traitSimilarity(a, sparse)
+    assert.False(t, ok)
+}
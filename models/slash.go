@@ -0,0 +1,253 @@
+package models
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+)
+
+const (
+    SlashInChannel = "in_channel"
+    SlashEphemeral = "ephemeral"
+
+    // Requests signed longer ago than this are rejected as possible replays.
+    slashMaxSkew = 5 * time.Minute
+
+    slashLeaderboardSize = 5
+)
+
+// SlashCommand is the part of a Slack-style slash-command payload we use.
+type SlashCommand struct {
+    Command     string
+    Text        string
+    UserID      string
+    UserName    string
+    ResponseURL string
+}
+
+// SlashMessage is a chat message in Slack's Block Kit format.
+type SlashMessage struct {
+    ResponseType string       `json:"response_type"`
+    Text         string       `json:"text"`
+    Blocks       []SlashBlock `json:"blocks,omitempty"`
+}
+
+type SlashBlock struct {
+    Type     string     `json:"type"`
+    Text     *SlashText `json:"text,omitempty"`
+    ImageURL string     `json:"image_url,omitempty"`
+    AltText  string     `json:"alt_text,omitempty"`
+}
+
+type SlashText struct {
+    Type string `json:"type"`
+    Text string `json:"text"`
+}
+
+// ParseSlashCommand reads a form-encoded slash-command payload.
+func ParseSlashCommand(body []byte) (SlashCommand, error) {
+    form, err := url.ParseQuery(string(body))
+    if err != nil {
+        return SlashCommand{}, err
+    }
+    return SlashCommand{
+        Command:     form.Get("command"),
+        Text:        strings.TrimSpace(form.Get("text")),
+        UserID:      form.Get("user_id"),
+        UserName:    form.Get("user_name"),
+        ResponseURL: form.Get("response_url"),
+    }, nil
+}
+
+// VerifySlashSignature checks Slack's request signature: "v0=" followed by
+// the hex HMAC-SHA256 of "v0:<timestamp>:<body>" keyed with the signing
+// secret.
+func VerifySlashSignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+    sent, err := strconv.ParseInt(timestamp, 10, 64)
+    if err != nil {
+        return fmt.Errorf("invalid request timestamp")
+    }
+    if skew := now.Sub(time.Unix(sent, 0)); skew > slashMaxSkew || skew < -slashMaxSkew {
+        return fmt.Errorf("request timestamp is too old")
+    }
+
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte("v0:" + timestamp + ":"))
+    mac.Write(body)
+    expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+    if !hmac.Equal([]byte(expected), []byte(signature)) {
+        return fmt.Errorf("invalid request signature")
+    }
+    return nil
+}
+
+// RunSlashCommand answers "/cat" with a random cat, "/cat top" with the
+// trending leaderboard and "/cat <breed>" with a breed card. Failures are
+// reported back to the caller only, as an ephemeral message.
+func RunSlashCommand(cmd SlashCommand) *SlashMessage {
+    var (
+        msg *SlashMessage
+        err error
+    )
+    switch text := strings.ToLower(cmd.Text); text {
+    case "":
+        msg, err = randomCatMessage()
+    case "top":
+        msg = leaderboardMessage()
+    case "help":
+        msg = slashHelp(cmd.Command)
+    default:
+        msg, err = breedMessage(cmd.Text)
+    }
+
+    if err != nil {
+        return &SlashMessage{ResponseType: SlashEphemeral, Text: "Sorry, " + err.Error()}
+    }
+    return msg
+}
+
+// SlashAcknowledgement is sent straight away when the answer will follow
+// through the response URL.
+func SlashAcknowledgement() *SlashMessage {
+    return &SlashMessage{ResponseType: SlashEphemeral, Text: "Fetching a cat…"}
+}
+
+// PostSlashResponse delivers a delayed answer to the command's response URL.
+func PostSlashResponse(responseURL string, msg *SlashMessage) error {
+    target, err := url.Parse(responseURL)
+    if err != nil || target.Scheme != "https" && target.Scheme != "http" {
+        return fmt.Errorf("invalid response url: %q", responseURL)
+    }
+
+    body, err := json.Marshal(msg)
+    if err != nil {
+        return err
+    }
+    client := &http.Client{Timeout: 10 * time.Second}
+    resp, err := client.Post(responseURL, "application/json", bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return fmt.Errorf("response url responded with status %d", resp.StatusCode)
+    }
+    return nil
+}
+
+func randomCatMessage() (*SlashMessage, error) {
+    cats, err := FetchCats()
+    if err != nil {
+        return nil, err
+    }
+    if len(cats) == 0 {
+        return nil, fmt.Errorf("no cats available right now")
+    }
+
+    cat := cats[0]
+    caption := "Here's a cat for you"
+    if len(cat.Breeds) > 0 {
+        caption = fmt.Sprintf("Here's a cat for you: *%s*", cat.Breeds[0].Name)
+    }
+    return &SlashMessage{
+        ResponseType: SlashInChannel,
+        Text:         cat.URL,
+        Blocks: []SlashBlock{
+            markdownBlock(caption),
+            imageBlock(cat.URL, "A random cat"),
+        },
+    }, nil
+}
+
+func breedMessage(name string) (*SlashMessage, error) {
+    breedID, err := ResolveBreedID(name)
+    if err != nil {
+        return nil, err
+    }
+    breed, err := FetchBreedDetails(breedID)
+    if err != nil {
+        return nil, err
+    }
+
+    summary := fmt.Sprintf("*%s*", breed.Name)
+    if breed.Origin != "" {
+        summary += fmt.Sprintf(" from %s", breed.Origin)
+    }
+    if breed.Temperament != "" {
+        summary += "\n_" + breed.Temperament + "_"
+    }
+    if breed.Description != "" {
+        summary += "\n" + breed.Description
+    }
+
+    msg := &SlashMessage{
+        ResponseType: SlashInChannel,
+        Text:         breed.Name,
+        Blocks:       []SlashBlock{markdownBlock(summary)},
+    }
+    if len(breed.Images) > 0 {
+        msg.Blocks = append(msg.Blocks, imageBlock(breed.Images[0], breed.Name))
+    }
+    return msg, nil
+}
+
+func leaderboardMessage() *SlashMessage {
+    trending := GetTrending(slashLeaderboardSize)
+    if len(trending.Images) == 0 {
+        return &SlashMessage{ResponseType: SlashInChannel, Text: "Nobody has voted recently. Be the first!"}
+    }
+
+    lines := []string{"*Trending cats*"}
+    for i, item := range trending.Images {
+        lines = append(lines, fmt.Sprintf("%d. <%s|%s> (%.1f)", i+1, item.URL, item.ID, item.Score))
+    }
+    if len(trending.Breeds) > 0 {
+        breeds := []string{}
+        for _, item := range trending.Breeds {
+            name := item.Name
+            if name == "" {
+                name = item.ID
+            }
+            breeds = append(breeds, name)
+        }
+        lines = append(lines, "Hot breeds: "+strings.Join(breeds, ", "))
+    }
+
+    msg := &SlashMessage{
+        ResponseType: SlashInChannel,
+        Text:         "Trending cats",
+        Blocks:       []SlashBlock{markdownBlock(strings.Join(lines, "\n"))},
+    }
+    if top := trending.Images[0]; top.URL != "" {
+        msg.Blocks = append(msg.Blocks, imageBlock(top.URL, "The top trending cat"))
+    }
+    return msg
+}
+
+func slashHelp(command string) *SlashMessage {
+    if command == "" {
+        command = "/cat"
+    }
+    return &SlashMessage{
+        ResponseType: SlashEphemeral,
+        Text: fmt.Sprintf("`%[1]s` shows a random cat, `%[1]s bengal` a breed and `%[1]s top` what is trending.",
+            command),
+    }
+}
+
+func markdownBlock(text string) SlashBlock {
+    return SlashBlock{Type: "section", Text: &SlashText{Type: "mrkdwn", Text: text}}
+}
+
+func imageBlock(imageURL, altText string) SlashBlock {
+    return SlashBlock{Type: "image", ImageURL: imageURL, AltText: altText}
+}
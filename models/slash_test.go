@@ -0,0 +1,87 @@
+package models
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "strconv"
+    "testing"
+    "time"
+)
+
+func signSlash(secret string, timestamp string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte("v0:" + timestamp + ":"))
+    mac.Write(body)
+    return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySlashSignature(t *testing.T) {
+    now := time.Now()
+    body := []byte("command=%2Fcat&text=top")
+    timestamp := strconv.FormatInt(now.Unix(), 10)
+
+    assert.NoError(t, VerifySlashSignature("secret", timestamp, signSlash("secret", timestamp, body), body, now))
+    assert.Error(t, VerifySlashSignature("other", timestamp, signSlash("secret", timestamp, body), body, now))
+    assert.Error(t, VerifySlashSignature("secret", timestamp, signSlash("secret", timestamp, body), []byte("text=bengal"), now))
+
+    stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+    assert.Error(t, VerifySlashSignature("secret", stale, signSlash("secret", stale, body), body, now))
+    assert.Error(t, VerifySlashSignature("secret", "yesterday", "v0=", body, now))
+}
+
+func TestParseSlashCommand(t *testing.T) {
+    cmd, err := ParseSlashCommand([]byte("command=%2Fcat&text=+bengal+&user_id=U1&response_url=https%3A%2F%2Fexample.com%2Fr"))
+    assert.NoError(t, err)
+    assert.Equal(t, "/cat", cmd.Command)
+    assert.Equal(t, "bengal", cmd.Text)
+    assert.Equal(t, "U1", cmd.UserID)
+    assert.Equal(t, "https://example.com/r", cmd.ResponseURL)
+}
+
+func TestRunSlashCommand(t *testing.T) {
+    withCatAPI(t, func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/images/search":
+            w.Write([]byte(`[{"id":"img1","url":"http://example.com/1.jpg","breeds":[{"id":"beng","name":"Bengal"}]}]`))
+        case "/breeds/bomb":
+            w.Write([]byte(`{"id":"bomb","name":"Bombay","origin":"United States","temperament":"Affectionate, Dependent"}`))
+        default:
+            http.NotFound(w, r)
+        }
+    })
+    SetBreedCatalog(sampleBreeds())
+
+    msg := RunSlashCommand(SlashCommand{Command: "/cat"})
+    assert.Equal(t, SlashInChannel, msg.ResponseType)
+    assert.Equal(t, "http://example.com/1.jpg", msg.Blocks[1].ImageURL)
+    assert.Contains(t, msg.Blocks[0].Text.Text, "Bengal")
+
+    msg = RunSlashCommand(SlashCommand{Command: "/cat", Text: "Bombai"})
+    assert.Equal(t, SlashInChannel, msg.ResponseType)
+    assert.Contains(t, msg.Blocks[0].Text.Text, "*Bombay* from United States")
+    assert.Equal(t, "image", msg.Blocks[1].Type)
+
+    msg = RunSlashCommand(SlashCommand{Command: "/cat", Text: "definitely not a breed"})
+    assert.Equal(t, SlashEphemeral, msg.ResponseType)
+    assert.Contains(t, msg.Text, "Sorry")
+
+    msg = RunSlashCommand(SlashCommand{Command: "/kitty", Text: "help"})
+    assert.Equal(t, SlashEphemeral, msg.ResponseType)
+    assert.Contains(t, msg.Text, "`/kitty top`")
+}
+
+func TestRunSlashCommandTop(t *testing.T) {
+    resetTrends()
+    RecordTrend(Vote{ID: "1", ImageID: "img1", ImageURL: "http://example.com/1.jpg", Value: VoteLove, CreatedAt: time.Now()})
+
+    msg := RunSlashCommand(SlashCommand{Command: "/cat", Text: "TOP"})
+    assert.Equal(t, SlashInChannel, msg.ResponseType)
+    assert.Contains(t, msg.Blocks[0].Text.Text, "1. <http://example.com/1.jpg|img1>")
+
+    resetTrends()
+    msg = RunSlashCommand(SlashCommand{Command: "/cat", Text: "top"})
+    assert.Contains(t, msg.Text, "Nobody has voted recently")
+}
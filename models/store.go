@@ -0,0 +1,173 @@
+package models
+
+import (
+    "bytes"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "github.com/beego/beego/v2/server/web"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+// dataDir is where state that must survive restarts is written, one JSON
+// file per kind of record.
+func dataDir() string {
+    return web.AppConfig.DefaultString("data_dir", "data")
+}
+
+// saveJSON writes v to name inside the data directory. It writes to a
+// temporary file first so a crash never leaves a half-written file behind.
+func saveJSON(name string, v interface{}) error {
+    dir := dataDir()
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return err
+    }
+
+    data, err := json.MarshalIndent(v, "", "  ")
+    if err != nil {
+        return err
+    }
+
+    path := filepath.Join(dir, name)
+    tmp := path + ".tmp"
+    if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
+
+// loadJSON reads name from the data directory into v. A missing file is not
+// an error and leaves v untouched.
+func loadJSON(name string, v interface{}) error {
+    data, err := ioutil.ReadFile(filepath.Join(dataDir(), name))
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    return json.Unmarshal(data, v)
+}
+
+// appendJSONLines appends each record to name as a line of JSON, in a
+// single write, so a record is never rewritten once stored. If the write
+// fails the file is cut back to where it was.
+func appendJSONLines(name string, records ...interface{}) error {
+    dir := dataDir()
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return err
+    }
+
+    data, err := encodeJSONLines(records)
+    if err != nil {
+        return err
+    }
+
+    f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+    if err != nil {
+        return err
+    }
+    info, err := f.Stat()
+    if err != nil {
+        f.Close()
+        return err
+    }
+    if _, err := f.Write(data); err != nil {
+        f.Truncate(info.Size())
+        f.Close()
+        return err
+    }
+    return f.Close()
+}
+
+// loadJSONLines calls fn with each line of name, oldest first. A missing
+// file is not an error. A last line cut short by a crash is skipped.
+func loadJSONLines(name string, fn func(line []byte) error) error {
+    data, err := ioutil.ReadFile(filepath.Join(dataDir(), name))
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    lines := bytes.Split(data, []byte("\n"))
+    for i, line := range lines {
+        if len(bytes.TrimSpace(line)) == 0 {
+            continue
+        }
+        if i == len(lines)-1 && !json.Valid(line) {
+            break
+        }
+        if err := fn(line); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// saveJSONLines replaces name with the records, one per line, the same
+// crash-safe way as saveJSON.
+func saveJSONLines(name string, records []interface{}) error {
+    dir := dataDir()
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return err
+    }
+
+    data, err := encodeJSONLines(records)
+    if err != nil {
+        return err
+    }
+
+    path := filepath.Join(dir, name)
+    tmp := path + ".tmp"
+    if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
+
+func encodeJSONLines(records []interface{}) ([]byte, error) {
+    var buf bytes.Buffer
+    for _, record := range records {
+        data, err := json.Marshal(record)
+        if err != nil {
+            return nil, err
+        }
+        buf.Write(data)
+        buf.WriteByte('\n')
+    }
+    return buf.Bytes(), nil
+}
+
+const secretsFile = "secrets.json"
+
+var secretsMutex sync.Mutex
+
+// Secret returns the random secret stored under name, generating it on
+// first use, for signing values that must stay valid across restarts.
+func Secret(name string) (string, error) {
+    secretsMutex.Lock()
+    defer secretsMutex.Unlock()
+
+    secrets := map[string]string{}
+    if err := loadJSON(secretsFile, &secrets); err != nil {
+        return "", err
+    }
+    if secret, ok := secrets[name]; ok {
+        return secret, nil
+    }
+
+    buf := make([]byte, 32)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    secrets[name] = hex.EncodeToString(buf)
+    if err := saveJSON(secretsFile, secrets); err != nil {
+        return "", err
+    }
+    return secrets[name], nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "os"
+    "testing"
+)
+
+func init() {
+    dir, err := ioutil.TempDir("", "catvoting-models")
+    if err != nil {
+        panic(err)
+    }
+    web.AppConfig.Set("data_dir", dir)
+}
+
+// withDataDir gives the test an empty data directory of its own.
+func withDataDir(t *testing.T) string {
+    original, _ := web.AppConfig.String("data_dir")
+    dir := t.TempDir()
+    web.AppConfig.Set("data_dir", dir)
+    t.Cleanup(func() {
+        web.AppConfig.Set("data_dir", original)
+    })
+    return dir
+}
+
+func TestSaveAndLoadJSON(t *testing.T) {
+    original, _ := web.AppConfig.String("data_dir")
+    dir := t.TempDir()
+    web.AppConfig.Set("data_dir", dir+"/nested")
+    defer web.AppConfig.Set("data_dir", original)
+
+    var missing []string
+    assert.NoError(t, loadJSON("missing.json", &missing))
+    assert.Nil(t, missing)
+
+    assert.NoError(t, saveJSON("items.json", []string{"a", "b"}))
+    var items []string
+    assert.NoError(t, loadJSON("items.json", &items))
+    assert.Equal(t, []string{"a", "b"}, items)
+
+    _, err := os.Stat(dir + "/nested/items.json.tmp")
+    assert.True(t, os.IsNotExist(err))
+}
+
+func TestSecret(t *testing.T) {
+    withDataDir(t)
+
+    secret, err := Secret("cookie")
+    assert.NoError(t, err)
+    assert.Len(t, secret, 64)
+
+    again, _ := Secret("cookie")
+    assert.Equal(t, secret, again, "the secret is kept")
+    other, _ := Secret("other")
+    assert.NotEqual(t, secret, other)
+}
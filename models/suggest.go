@@ -0,0 +1,256 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "unicode"
+    "unicode/utf8"
+)
+
+type BreedSuggestion struct {
+    ID        string `json:"id"`
+    Name      string `json:"name"`
+    MatchedOn string `json:"matched_on"`
+    Distance  int    `json:"distance"`
+}
+
+// suggestEntry is one searchable key for a breed: its ID, its name, an
+// alternate name, or any word-suffix of those so "shorthair" finds
+// "American Shorthair".
+type suggestEntry struct {
+    Key     string
+    Label   string
+    BreedID string
+    Full    bool
+}
+
+var (
+    suggestEntries []suggestEntry // sorted by Key
+    breedNames     map[string]string
+    suggestMutex   sync.RWMutex
+)
+
+// SuggestBreeds returns breeds whose name or alternate names start with the
+// given prefix. When nothing matches it falls back to edit distance, so
+// "bomab" still suggests Bombay.
+func SuggestBreeds(prefix string, limit int) ([]BreedSuggestion, error) {
+    if err := ensureSuggestIndex(); err != nil {
+        return nil, err
+    }
+
+    key := normalizeName(prefix)
+    suggestions := []BreedSuggestion{}
+    if key == "" {
+        return suggestions, nil
+    }
+
+    suggestMutex.RLock()
+    defer suggestMutex.RUnlock()
+
+    seen := make(map[string]bool)
+    start := sort.Search(len(suggestEntries), func(i int) bool {
+        return suggestEntries[i].Key >= key
+    })
+    for i := start; i < len(suggestEntries) && strings.HasPrefix(suggestEntries[i].Key, key); i++ {
+        entry := suggestEntries[i]
+        if seen[entry.BreedID] {
+            continue
+        }
+        seen[entry.BreedID] = true
+        suggestions = append(suggestions, BreedSuggestion{
+            ID:        entry.BreedID,
+            Name:      breedNames[entry.BreedID],
+            MatchedOn: entry.Label,
+        })
+    }
+
+    if len(suggestions) == 0 {
+        // Compare against the same-length prefix of each key so a typo in a
+        // partially typed name is still forgiven.
+        best := make(map[string]BreedSuggestion)
+        keyLen := len([]rune(key))
+        for _, entry := range suggestEntries {
+            candidate := entry.Key
+            if runes := []rune(candidate); len(runes) > keyLen {
+                candidate = string(runes[:keyLen])
+            }
+            distance := editDistance(key, candidate)
+            if distance > maxTypos(key) {
+                continue
+            }
+            if current, ok := best[entry.BreedID]; !ok || distance < current.Distance {
+                best[entry.BreedID] = BreedSuggestion{
+                    ID:        entry.BreedID,
+                    Name:      breedNames[entry.BreedID],
+                    MatchedOn: entry.Label,
+                    Distance:  distance,
+                }
+            }
+        }
+        for _, suggestion := range best {
+            suggestions = append(suggestions, suggestion)
+        }
+    }
+
+    sort.SliceStable(suggestions, func(i, j int) bool {
+        if suggestions[i].Distance != suggestions[j].Distance {
+            return suggestions[i].Distance < suggestions[j].Distance
+        }
+        return suggestions[i].Name < suggestions[j].Name
+    })
+
+    if limit > 0 && len(suggestions) > limit {
+        suggestions = suggestions[:limit]
+    }
+    return suggestions, nil
+}
+
+// ResolveBreedID maps a breed ID, name, alternate name or a close
+// misspelling of one to the canonical breed ID.
+func ResolveBreedID(name string) (string, error) {
+    if err := ensureSuggestIndex(); err != nil {
+        return "", err
+    }
+
+    key := normalizeName(name)
+    if key == "" {
+        return "", fmt.Errorf("breed name is required")
+    }
+
+    suggestMutex.RLock()
+    defer suggestMutex.RUnlock()
+
+    bestID := ""
+    bestDistance := maxTypos(key) + 1
+    for _, entry := range suggestEntries {
+        if !entry.Full {
+            continue
+        }
+        if entry.Key == key {
+            return entry.BreedID, nil
+        }
+        if distance := editDistance(key, entry.Key); distance < bestDistance {
+            bestID = entry.BreedID
+            bestDistance = distance
+        }
+    }
+
+    if bestID == "" {
+        return "", fmt.Errorf("no breed matches %q", name)
+    }
+    return bestID, nil
+}
+
+func ensureSuggestIndex() error {
+    suggestMutex.RLock()
+    loaded := breedNames != nil
+    suggestMutex.RUnlock()
+
+    if loaded {
+        return nil
+    }
+    _, err := GetBreedCatalog()
+    return err
+}
+
+func rebuildSuggestIndex(breeds []Breed) {
+    entries := []suggestEntry{}
+    names := make(map[string]string, len(breeds))
+
+    for _, breed := range breeds {
+        names[breed.ID] = breed.Name
+        labels := []string{breed.ID, breed.Name}
+        for _, alt := range strings.Split(breed.AltNames, ",") {
+            if alt = strings.TrimSpace(alt); alt != "" {
+                labels = append(labels, alt)
+            }
+        }
+
+        for _, label := range labels {
+            key := normalizeName(label)
+            if key == "" {
+                continue
+            }
+            entries = append(entries, suggestEntry{Key: key, Label: label, BreedID: breed.ID, Full: true})
+
+            words := strings.Fields(key)
+            for i := 1; i < len(words); i++ {
+                entries = append(entries, suggestEntry{
+                    Key:     strings.Join(words[i:], " "),
+                    Label:   label,
+                    BreedID: breed.ID,
+                })
+            }
+        }
+    }
+
+    sort.Slice(entries, func(i, j int) bool {
+        return entries[i].Key < entries[j].Key
+    })
+
+    suggestMutex.Lock()
+    suggestEntries = entries
+    breedNames = names
+    suggestMutex.Unlock()
+}
+
+// normalizeName lowercases a name and reduces punctuation and repeated
+// whitespace to single spaces.
+func normalizeName(name string) string {
+    words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    return strings.Join(words, " ")
+}
+
+func maxTypos(key string) int {
+    n := utf8.RuneCountInString(key)
+    switch {
+    case n < 3:
+        return 0
+    case n < 7:
+        return 1
+    default:
+        return 2
+    }
+}
+
+// editDistance is the Levenshtein distance between a and b, counting an
+// adjacent transposition as a single edit.
+func editDistance(a, b string) int {
+    ra, rb := []rune(a), []rune(b)
+    prev2 := make([]int, len(rb)+1)
+    prev := make([]int, len(rb)+1)
+    curr := make([]int, len(rb)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+
+    for i := 1; i <= len(ra); i++ {
+        curr[0] = i
+        for j := 1; j <= len(rb); j++ {
+            cost := 1
+            if ra[i-1] == rb[j-1] {
+                cost = 0
+            }
+            curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+            if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+                curr[j] = minInt(curr[j], prev2[j-2]+1)
+            }
+        }
+        prev2, prev, curr = prev, curr, prev2
+    }
+    return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+    min := values[0]
+    for _, v := range values[1:] {
+        if v < min {
+            min = v
+        }
+    }
+    return min
+}
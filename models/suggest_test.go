@@ -0,0 +1,71 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+)
+
+func TestSuggestBreedsPrefix(t *testing.T) {
+    breeds := sampleBreeds()
+    breeds[3].AltNames = "Moscow Semi-longhair, Siberian Forest Cat"
+    SetBreedCatalog(breeds)
+
+    suggestions, err := SuggestBreeds("Ba", 10)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(suggestions))
+    assert.Equal(t, "bali", suggestions[0].ID)
+
+    // Word-suffix and alternate-name matches.
+    suggestions, err = SuggestBreeds("semi", 10)
+    assert.NoError(t, err)
+    assert.Equal(t, "sibe", suggestions[0].ID)
+    assert.Equal(t, "Moscow Semi-longhair", suggestions[0].MatchedOn)
+}
+
+func TestSuggestBreedsTypoFallback(t *testing.T) {
+    SetBreedCatalog(sampleBreeds())
+
+    suggestions, err := SuggestBreeds("bomab", 10)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(suggestions))
+    assert.Equal(t, "bomb", suggestions[0].ID)
+    assert.Equal(t, 1, suggestions[0].Distance)
+
+    // Keys are cut on characters, not bytes.
+    suggestions, err = SuggestBreeds("bömba", 10)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(suggestions))
+    assert.Equal(t, "bomb", suggestions[0].ID)
+    assert.Equal(t, 1, suggestions[0].Distance)
+
+    suggestions, err = SuggestBreeds("zzzz", 10)
+    assert.NoError(t, err)
+    assert.Empty(t, suggestions)
+}
+
+func TestResolveBreedID(t *testing.T) {
+    SetBreedCatalog(sampleBreeds())
+
+    tests := map[string]string{
+        "Bombay":     "bomb",
+        "bomb":       "bomb",
+        "  SIBERIAN": "sibe",
+        "Abyssinain": "abys",
+        "balinse":    "bali",
+    }
+    for name, want := range tests {
+        id, err := ResolveBreedID(name)
+        assert.NoError(t, err, name)
+        assert.Equal(t, want, id, name)
+    }
+
+    _, err := ResolveBreedID("Maine Coon")
+    assert.Error(t, err)
+}
+
+func TestEditDistance(t *testing.T) {
+    assert.Equal(t, 0, editDistance("bombay", "bombay"))
+    assert.Equal(t, 1, editDistance("bomaby", "bombay"))
+    assert.Equal(t, 3, editDistance("", "abc"))
+    assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
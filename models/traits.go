@@ -0,0 +1,78 @@
+package models
+
+import (
+    "strconv"
+    "strings"
+)
+
+// Trait describes one of the 1–5 scores TheCatAPI reports for each breed.
+type Trait struct {
+    Key   string `json:"key"`
+    Label string `json:"label"`
+}
+
+var breedTraits = []Trait{
+    {"adaptability", "Adaptability"},
+    {"affection_level", "Affection level"},
+    {"child_friendly", "Child friendly"},
+    {"dog_friendly", "Dog friendly"},
+    {"energy_level", "Energy level"},
+    {"grooming", "Grooming"},
+    {"health_issues", "Health issues"},
+    {"intelligence", "Intelligence"},
+    {"shedding_level", "Shedding level"},
+    {"social_needs", "Social needs"},
+    {"stranger_friendly", "Stranger friendly"},
+    {"vocalisation", "Vocalisation"},
+}
+
+// Range is a parsed "min - max" span such as a breed's life span or weight.
+type Range struct {
+    Min float64 `json:"min"`
+    Max float64 `json:"max"`
+}
+
+// BreedTraits returns the trait definitions in display order.
+func BreedTraits() []Trait {
+    return append([]Trait{}, breedTraits...)
+}
+
+// TraitScores returns the breed's trait scores keyed by Trait.Key.
+func TraitScores(breed Breed) map[string]int {
+    return map[string]int{
+        "adaptability":      breed.Adaptability,
+        "affection_level":   breed.AffectionLevel,
+        "child_friendly":    breed.ChildFriendly,
+        "dog_friendly":      breed.DogFriendly,
+        "energy_level":      breed.EnergyLevel,
+        "grooming":          breed.Grooming,
+        "health_issues":     breed.HealthIssues,
+        "intelligence":      breed.Intelligence,
+        "shedding_level":    breed.SheddingLevel,
+        "social_needs":      breed.SocialNeeds,
+        "stranger_friendly": breed.StrangerFriendly,
+        "vocalisation":      breed.Vocalisation,
+    }
+}
+
+// parseRange reads TheCatAPI's "12 - 15" style spans. A single number is
+// returned as both bounds; anything unparsable yields a zero Range.
+func parseRange(value string) Range {
+    parts := strings.Split(value, "-")
+    bounds := make([]float64, 0, 2)
+    for _, part := range parts {
+        n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+        if err != nil {
+            return Range{}
+        }
+        bounds = append(bounds, n)
+    }
+
+    switch len(bounds) {
+    case 1:
+        return Range{Min: bounds[0], Max: bounds[0]}
+    case 2:
+        return Range{Min: bounds[0], Max: bounds[1]}
+    }
+    return Range{}
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+    "fmt"
+    "github.com/beego/beego/v2/server/web"
+    "sort"
+    "time"
+)
+
+// TrashedFavorite is a removed favorite kept for favorites_trash_retention
+// so it can be restored. Like the favorites, the trash is shared; UserID
+// records who removed it.
+type TrashedFavorite struct {
+    FavoriteImage
+    UserID    string    `json:"user_id"`
+    DeletedAt time.Time `json:"deleted_at"`
+    ExpiresAt time.Time `json:"expires_at"`
+}
+
+// favoriteTrash is guarded by favMutex and persisted with the favorites.
+var favoriteTrash []TrashedFavorite
+
+// TrashFavorite moves a favorite to the trash on behalf of userID.
+func TrashFavorite(userID string, id string) error {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return err
+    }
+    if err := trashFavorite(userID, id, time.Now()); err != nil {
+        return err
+    }
+    return saveFavorites()
+}
+
+// GetFavoriteTrash lists the trash, most recently removed first.
+func GetFavoriteTrash() ([]TrashedFavorite, error) {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    result := append([]TrashedFavorite{}, favoriteTrash...)
+    sort.SliceStable(result, func(i, j int) bool {
+        return result[i].DeletedAt.After(result[j].DeletedAt)
+    })
+    return result, nil
+}
+
+// RestoreFavorite moves a favorite from the trash back to the end of the
+// favorites, in its old collection if that still exists.
+func RestoreFavorite(id string) (*FavoriteImage, error) {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+    fav, err := restoreFavorite(id)
+    if err != nil {
+        return nil, err
+    }
+    return fav, saveFavorites()
+}
+
+// PurgeFavoriteTrash permanently deletes trashed favorites that expired by
+// now. It returns how many were deleted.
+func PurgeFavoriteTrash(now time.Time) (int, error) {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return 0, err
+    }
+
+    trash := favoriteTrash[:0:0]
+    for _, item := range favoriteTrash {
+        if item.ExpiresAt.After(now) {
+            trash = append(trash, item)
+        }
+    }
+    purged := len(favoriteTrash) - len(trash)
+    if purged == 0 {
+        return 0, nil
+    }
+    favoriteTrash = trash
+    return purged, saveFavorites()
+}
+
+// trashFavorite moves a favorite to the trash without saving. Callers must
+// hold favMutex.
+func trashFavorite(userID string, id string, now time.Time) error {
+    for i, fav := range favorites {
+        if fav.ID != id {
+            continue
+        }
+        favorites = append(favorites[:i:i], favorites[i+1:]...)
+
+        // Only the latest removal of an image is kept.
+        trash := favoriteTrash[:0:0]
+        for _, item := range favoriteTrash {
+            if item.ID != id {
+                trash = append(trash, item)
+            }
+        }
+        favoriteTrash = append(trash, TrashedFavorite{
+            FavoriteImage: fav,
+            UserID:        userID,
+            DeletedAt:     now,
+            ExpiresAt:     now.Add(trashRetention()),
+        })
+        return nil
+    }
+    return fmt.Errorf("favorite not found with ID: %s", id)
+}
+
+// restoreFavorite moves a favorite out of the trash without saving.
+// Callers must hold favMutex.
+func restoreFavorite(id string) (*FavoriteImage, error) {
+    for i, item := range favoriteTrash {
+        if item.ID != id {
+            continue
+        }
+        for _, fav := range favorites {
+            if fav.ID == id {
+                return nil, ErrAlreadyFavorite
+            }
+        }
+
+        fav := item.FavoriteImage
+        if _, err := findCollection(fav.Collection); err != nil {
+            fav.Collection = ""
+        }
+        favoriteTrash = append(favoriteTrash[:i:i], favoriteTrash[i+1:]...)
+        favorites = append(favorites, fav)
+        return &fav, nil
+    }
+    return nil, fmt.Errorf("favorite not found in trash: %s", id)
+}
+
+func trashRetention() time.Duration {
+    retention, err := time.ParseDuration(web.AppConfig.DefaultString("favorites_trash_retention", "720h"))
+    if err != nil || retention <= 0 {
+        retention = 30 * 24 * time.Hour
+    }
+    return retention
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func TestTrashAndRestoreFavorite(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+    desk, _ := CreateCollection("Desk")
+    AddToCollection(desk.ID, "a", "")
+
+    assert.NoError(t, TrashFavorite("alice", "a"))
+    assert.Error(t, TrashFavorite("alice", "a"))
+    assert.Equal(t, []string{"b"}, favoriteIDs(GetFavorites()))
+
+    trash, err := GetFavoriteTrash()
+    assert.NoError(t, err)
+    assert.Equal(t, 1, len(trash))
+    assert.Equal(t, "a", trash[0].ID)
+    assert.Equal(t, "alice", trash[0].UserID)
+    assert.True(t, trash[0].ExpiresAt.After(trash[0].DeletedAt))
+
+    _, err = RestoreFavorite("c")
+    assert.Error(t, err)
+
+    fav, err := RestoreFavorite("a")
+    assert.NoError(t, err)
+    assert.Equal(t, desk.ID, fav.Collection)
+    assert.Equal(t, []string{"b", "a"}, favoriteIDs(GetFavorites()))
+    trash, _ = GetFavoriteTrash()
+    assert.Empty(t, trash)
+}
+
+func TestTrashIsShared(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    TrashFavorite("alice", "a")
+    SaveFavorite("a", "http://example.com/a.jpg")
+    TrashFavorite("bob", "a")
+
+    // Like the favorites, anyone can see and restore what anyone removed.
+    trash, _ := GetFavoriteTrash()
+    assert.Equal(t, 1, len(trash), "only the latest removal is kept")
+    assert.Equal(t, "bob", trash[0].UserID)
+
+    _, err := RestoreFavorite("a")
+    assert.NoError(t, err)
+    assert.Equal(t, []string{"a"}, favoriteIDs(GetFavorites()))
+}
+
+func TestRestoreFavoriteAlreadySavedAgain(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    TrashFavorite("alice", "a")
+    SaveFavorite("a", "http://example.com/a.jpg")
+
+    _, err := RestoreFavorite("a")
+    assert.Error(t, err)
+    assert.Equal(t, 1, len(GetFavorites()))
+}
+
+func TestPurgeFavoriteTrash(t *testing.T) {
+    resetCollections()
+    SaveFavorite("a", "http://example.com/a.jpg")
+    SaveFavorite("b", "http://example.com/b.jpg")
+    TrashFavorite("alice", "a")
+    TrashFavorite("alice", "b")
+
+    purged, err := PurgeFavoriteTrash(time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, 0, purged)
+
+    favMutex.Lock()
+    favoriteTrash[0].ExpiresAt = time.Now().Add(-time.Minute)
+    favMutex.Unlock()
+
+    purged, err = PurgeFavoriteTrash(time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, 1, purged)
+    trash, _ := GetFavoriteTrash()
+    assert.Equal(t, 1, len(trash))
+    assert.Equal(t, "b", trash[0].ID)
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+    "github.com/beego/beego/v2/server/web"
+    "math"
+    "sort"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// Scores that have decayed below this are dropped.
+const minTrendScore = 0.01
+
+type TrendingItem struct {
+    ID    string  `json:"id"`
+    Name  string  `json:"name,omitempty"`
+    URL   string  `json:"url,omitempty"`
+    Score float64 `json:"score"`
+}
+
+type Trending struct {
+    HalfLife string         `json:"half_life"`
+    Images   []TrendingItem `json:"images"`
+    Breeds   []TrendingItem `json:"breeds"`
+}
+
+// trendEntry holds a score as of updated; its value at any later time is
+// score * 2^-(elapsed/half-life), so it only needs touching when a vote
+// arrives.
+type trendEntry struct {
+    item    TrendingItem
+    score   float64
+    updated time.Time
+}
+
+var (
+    imageTrends   = make(map[string]*trendEntry)
+    breedTrends   = make(map[string]*trendEntry)
+    trendsThrough int // highest vote ID counted by the startup replay
+    trendsLoaded  bool
+    trendMutex    sync.Mutex
+)
+
+// trendingHalfLife is how long it takes a vote's contribution to halve,
+// configured as trending_half_life (a Go duration, default 6h).
+func trendingHalfLife() time.Duration {
+    halfLife, err := time.ParseDuration(web.AppConfig.DefaultString("trending_half_life", "6h"))
+    if err != nil || halfLife <= 0 {
+        return 6 * time.Hour
+    }
+    return halfLife
+}
+
+// RecordTrend folds one vote into the hot scores of its image and breeds.
+// It is called as each vote is processed, so reading the scores never
+// rescans the ledger. Votes arrive from several workers and so not always
+// in ID order; only those the startup replay already counted are skipped.
+func RecordTrend(vote Vote) {
+    trendMutex.Lock()
+    defer trendMutex.Unlock()
+
+    loadTrends()
+    if seq, _ := strconv.Atoi(vote.ID); seq != 0 && seq <= trendsThrough {
+        return
+    }
+    bumpVote(vote, float64(voteScore[vote.Value]), trendingHalfLife())
+}
+
+// RetractTrend takes a retracted vote's contribution back out of the hot
+// scores, decayed the same way, so they end up as if it was never cast.
+func RetractTrend(vote Vote) {
+    trendMutex.Lock()
+    defer trendMutex.Unlock()
+
+    if !trendsLoaded {
+        // The ledger no longer holds the vote, so replaying it is enough.
+        loadTrends()
+        return
+    }
+    bumpVote(vote, -float64(voteScore[vote.Value]), trendingHalfLife())
+}
+
+// GetTrending returns the hottest images and breeds right now.
+func GetTrending(limit int) Trending {
+    trendMutex.Lock()
+    defer trendMutex.Unlock()
+
+    loadTrends()
+    halfLife := trendingHalfLife()
+    now := time.Now()
+    return Trending{
+        HalfLife: halfLife.String(),
+        Images:   hottest(imageTrends, now, halfLife, limit),
+        Breeds:   hottest(breedTrends, now, halfLife, limit),
+    }
+}
+
+// loadTrends replays the persisted ledger once at startup. Callers must hold
+// trendMutex.
+func loadTrends() {
+    if trendsLoaded {
+        return
+    }
+    trendsLoaded = true
+
+    halfLife := trendingHalfLife()
+    for _, vote := range GetVotes("") {
+        if seq, _ := strconv.Atoi(vote.ID); seq > trendsThrough {
+            trendsThrough = seq
+        }
+        bumpVote(vote, float64(voteScore[vote.Value]), halfLife)
+    }
+}
+
+func bumpVote(vote Vote, weight float64, halfLife time.Duration) {
+    bump(imageTrends, TrendingItem{ID: vote.ImageID, URL: vote.ImageURL}, weight, vote.CreatedAt, halfLife)
+    for _, breedID := range vote.BreedIDs {
+        bump(breedTrends, TrendingItem{ID: breedID, Name: cachedBreedName(breedID)}, weight, vote.CreatedAt, halfLife)
+    }
+}
+
+func bump(trends map[string]*trendEntry, item TrendingItem, weight float64, at time.Time, halfLife time.Duration) {
+    entry, ok := trends[item.ID]
+    if !ok {
+        trends[item.ID] = &trendEntry{item: item, score: weight, updated: at}
+        return
+    }
+
+    if at.After(entry.updated) {
+        entry.score = decay(entry.score, at.Sub(entry.updated), halfLife)
+        entry.updated = at
+    } else {
+        // An out-of-order vote is decayed to the entry's time instead.
+        weight = decay(weight, entry.updated.Sub(at), halfLife)
+    }
+    entry.score += weight
+    if entry.item.Name == "" {
+        entry.item.Name = item.Name
+    }
+}
+
+func hottest(trends map[string]*trendEntry, now time.Time, halfLife time.Duration, limit int) []TrendingItem {
+    items := []TrendingItem{}
+    for id, entry := range trends {
+        score := decay(entry.score, now.Sub(entry.updated), halfLife)
+        if math.Abs(score) < minTrendScore {
+            delete(trends, id)
+            continue
+        }
+        if score <= 0 {
+            continue
+        }
+        item := entry.item
+        item.Score = math.Round(score*1000) / 1000
+        items = append(items, item)
+    }
+
+    sort.Slice(items, func(i, j int) bool {
+        if items[i].Score != items[j].Score {
+            return items[i].Score > items[j].Score
+        }
+        return items[i].ID < items[j].ID
+    })
+    if limit > 0 && len(items) > limit {
+        items = items[:limit]
+    }
+    return items
+}
+
+func decay(score float64, elapsed time.Duration, halfLife time.Duration) float64 {
+    if elapsed <= 0 {
+        return score
+    }
+    return score * math.Pow(2, -float64(elapsed)/float64(halfLife))
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func resetTrends() {
+    trendMutex.Lock()
+    imageTrends = make(map[string]*trendEntry)
+    breedTrends = make(map[string]*trendEntry)
+    trendsThrough = 0
+    trendsLoaded = true
+    trendMutex.Unlock()
+}
+
+func TestTrendingDecay(t *testing.T) {
+    resetTrends()
+    web.AppConfig.Set("trending_half_life", "1h")
+    defer web.AppConfig.Set("trending_half_life", "6h")
+
+    now := time.Now()
+    RecordTrend(Vote{ID: "1", ImageID: "old", Value: VoteLove, CreatedAt: now.Add(-2 * time.Hour), BreedIDs: []string{"abys"}})
+    RecordTrend(Vote{ID: "2", ImageID: "new", Value: VoteLike, CreatedAt: now, BreedIDs: []string{"beng"}})
+    RecordTrend(Vote{ID: "3", ImageID: "meh", Value: VoteDislike, CreatedAt: now})
+
+    trending := GetTrending(10)
+    assert.Equal(t, "1h0m0s", trending.HalfLife)
+    assert.Equal(t, 2, len(trending.Images), "images with a negative score are not trending")
+    assert.Equal(t, "new", trending.Images[0].ID)
+    assert.InDelta(t, 1.0, trending.Images[0].Score, 0.01)
+    // A love is worth 2, halved twice over two hours.
+    assert.InDelta(t, 0.5, trending.Images[1].Score, 0.01)
+    assert.Equal(t, "beng", trending.Breeds[0].ID)
+
+    // Workers publish votes out of ID order; a late one still counts.
+    RecordTrend(Vote{ID: "5", ImageID: "late", Value: VoteLove, CreatedAt: now})
+    RecordTrend(Vote{ID: "4", ImageID: "late", Value: VoteLove, CreatedAt: now})
+    assert.InDelta(t, 4.0, GetTrending(1).Images[0].Score, 0.01)
+}
+
+func TestTrendingReplaysLedger(t *testing.T) {
+    resetVotes()
+    RecordVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    RecordVote("bob", VoteRequest{ImageID: "img1", Vote: VoteLike})
+
+    trendMutex.Lock()
+    imageTrends = make(map[string]*trendEntry)
+    breedTrends = make(map[string]*trendEntry)
+    trendsThrough = 0
+    trendsLoaded = false
+    trendMutex.Unlock()
+
+    trending := GetTrending(10)
+    assert.Equal(t, 1, len(trending.Images))
+    assert.InDelta(t, 3.0, trending.Images[0].Score, 0.01)
+
+    // The events of the replayed votes aren't counted twice.
+    for _, vote := range GetVotes("") {
+        RecordTrend(vote)
+    }
+    assert.InDelta(t, 3.0, GetTrending(10).Images[0].Score, 0.01)
+}
+
+func TestRetractTrend(t *testing.T) {
+    resetTrends()
+    now := time.Now()
+
+    love := Vote{ID: "1", ImageID: "img1", Value: VoteLove, CreatedAt: now.Add(-time.Hour), BreedIDs: []string{"abys"}}
+    RecordTrend(love)
+    RecordTrend(Vote{ID: "2", ImageID: "img1", Value: VoteLike, CreatedAt: now})
+
+    RetractTrend(love)
+    trending := GetTrending(10)
+    assert.Equal(t, 1, len(trending.Images))
+    assert.InDelta(t, 1.0, trending.Images[0].Score, 0.01)
+    assert.Empty(t, trending.Breeds, "the breed only had the retracted vote")
+}
+
+func TestDecay(t *testing.T) {
+    assert.Equal(t, 4.0, decay(4, 0, time.Hour))
+    assert.InDelta(t, 2.0, decay(4, time.Hour, time.Hour), 0.0001)
+}
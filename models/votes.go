@@ -0,0 +1,336 @@
+package models
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "strconv"
+    "sync"
+    "time"
+)
+
+const (
+    VoteLike    = "like"
+    VoteDislike = "dislike"
+    VoteLove    = "love"
+)
+
+// Vote is one entry in the vote ledger.
+type Vote struct {
+    ID        string    `json:"id"`
+    UserID    string    `json:"user_id"`
+    ImageID   string    `json:"image_id"`
+    ImageURL  string    `json:"image_url"`
+    BreedIDs  []string  `json:"breed_ids,omitempty"`
+    Value     string    `json:"vote"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+    // votesFile is the ledger as an append-only log of voteRecords, so
+    // casting a vote doesn't rewrite every earlier one.
+    votesFile = "votes.log"
+)
+
+// voteRecord is one line of the vote log: a vote, the retraction of one,
+// or, at the start of a compacted log, the last vote ID handed out, so
+// retracted IDs are never reused.
+type voteRecord struct {
+    Vote      *Vote  `json:"vote,omitempty"`
+    Retracted string `json:"retracted,omitempty"`
+    Seq       int    `json:"seq,omitempty"`
+}
+
+var (
+    votes       []Vote
+    voteSeq     int
+    votesLoaded bool
+    voteMutex   sync.Mutex
+)
+
+// Validate checks that the request names an image and a known vote value.
+func (v VoteRequest) Validate() error {
+    if v.ImageID == "" {
+        return fmt.Errorf("image id is required")
+    }
+    switch v.Vote {
+    case VoteLike, VoteDislike, VoteLove:
+        return nil
+    }
+    return fmt.Errorf("invalid vote: %q", v.Vote)
+}
+
+// RecordVote appends a vote to the ledger, attributing it to the breeds of
+// the image when the image was served recently.
+func RecordVote(userID string, req VoteRequest) (Vote, error) {
+    if err := req.Validate(); err != nil {
+        return Vote{}, err
+    }
+    recorded, err := recordVotes(userID, []VoteRequest{req})
+    if err != nil {
+        return Vote{}, err
+    }
+    return recorded[0], nil
+}
+
+// CastVote records a vote from the voting stream. A love also adds the
+// image to the favorites, and the vote is only kept if that worked and the
+// favorite is only kept if the vote was recorded.
+func CastVote(userID string, req VoteRequest) (Vote, error) {
+    if err := req.Validate(); err != nil {
+        return Vote{}, err
+    }
+    if req.Vote != VoteLove {
+        return RecordVote(userID, req)
+    }
+    image := cachedImage(req.ImageID)
+
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return Vote{}, err
+    }
+    undo := snapshotFavorites()
+    if err := addFavorite(req.ImageID, req.ImageURL, image); err != nil {
+        return Vote{}, err
+    }
+    recorded, err := saveFavoritesAndRecordVotes(userID, []VoteRequest{req}, undo)
+    if err != nil {
+        return Vote{}, err
+    }
+    return recorded[0], nil
+}
+
+// saveFavoritesAndRecordVotes saves favorites the caller changed for the
+// votes, then records them. If the votes can't be recorded the favorites
+// are put back with undo, so a love never leaves a favorite without its
+// vote. Callers must hold favMutex.
+func saveFavoritesAndRecordVotes(userID string, reqs []VoteRequest, undo func()) ([]Vote, error) {
+    if err := saveFavorites(); err != nil {
+        undo()
+        return nil, err
+    }
+    recorded, err := recordVotes(userID, reqs)
+    if err != nil {
+        undo()
+        if saveErr := saveFavorites(); saveErr != nil {
+            logs.Error("Error restoring favorites after a failed vote: %v", saveErr)
+        }
+        return nil, err
+    }
+    return recorded, nil
+}
+
+// recordVotes appends already validated votes to the ledger in one write:
+// either all of them are recorded or none are.
+func recordVotes(userID string, reqs []VoteRequest) ([]Vote, error) {
+    now := time.Now()
+    recorded := make([]Vote, 0, len(reqs))
+    for _, req := range reqs {
+        vote := Vote{
+            UserID:    userID,
+            ImageID:   req.ImageID,
+            ImageURL:  req.ImageURL,
+            Value:     req.Vote,
+            CreatedAt: now,
+        }
+        if cat, ok := LookupCat(req.ImageID); ok {
+            for _, breed := range cat.Breeds {
+                vote.BreedIDs = append(vote.BreedIDs, breed.ID)
+            }
+        }
+        recorded = append(recorded, vote)
+    }
+
+    voteMutex.Lock()
+    defer voteMutex.Unlock()
+
+    if err := loadVotes(); err != nil {
+        return nil, err
+    }
+
+    seq, count := voteSeq, len(votes)
+    records := make([]interface{}, 0, len(recorded))
+    for i := range recorded {
+        voteSeq++
+        recorded[i].ID = strconv.Itoa(voteSeq)
+        votes = append(votes, recorded[i])
+        records = append(records, voteRecord{Vote: &recorded[i]})
+    }
+    if err := appendJSONLines(votesFile, records...); err != nil {
+        votes, voteSeq = votes[:count], seq
+        return nil, err
+    }
+    return recorded, nil
+}
+
+// RetractedVote is a vote taken back out of the ledger. FavoriteRemoved
+// is set when undoing a love also moved the favorite it added to the
+// trash.
+type RetractedVote struct {
+    Vote
+    FavoriteRemoved bool `json:"favorite_removed"`
+}
+
+// RetractVote takes one of the user's votes back out of the ledger, as if
+// it had never been cast. Undoing a love also moves the image from the
+// favorites to the trash, unless a love for it, anyone's, still stands.
+func RetractVote(userID string, voteID string) (*RetractedVote, error) {
+    return retractVote(userID, func() int {
+        for i, vote := range votes {
+            if vote.ID == voteID && vote.UserID == userID {
+                return i
+            }
+        }
+        return -1
+    })
+}
+
+// UndoLastVote retracts the user's most recent vote.
+func UndoLastVote(userID string) (*RetractedVote, error) {
+    return retractVote(userID, func() int {
+        for i := len(votes) - 1; i >= 0; i-- {
+            if votes[i].UserID == userID {
+                return i
+            }
+        }
+        return -1
+    })
+}
+
+// retractVote removes the vote at the index find returns. find is called
+// with voteMutex held.
+func retractVote(userID string, find func() int) (*RetractedVote, error) {
+    favMutex.Lock()
+    defer favMutex.Unlock()
+
+    if err := loadFavorites(); err != nil {
+        return nil, err
+    }
+
+    voteMutex.Lock()
+    defer voteMutex.Unlock()
+
+    if err := loadVotes(); err != nil {
+        return nil, err
+    }
+
+    i := find()
+    if i < 0 {
+        return nil, fmt.Errorf("vote not found")
+    }
+    retracted := &RetractedVote{Vote: votes[i]}
+    kept := votes
+    votes = append(votes[:i:i], votes[i+1:]...)
+
+    // The favorite is moved to the trash and saved before the retraction
+    // is recorded, so a failure leaves both the vote and the favorite.
+    undo := snapshotFavorites()
+    if retracted.Value == VoteLove && !loved(retracted.ImageID) {
+        // An error means the favorite was already removed by hand.
+        if err := trashFavorite(userID, retracted.ImageID, time.Now()); err == nil {
+            if err := saveFavoritesOrUndo(undo); err != nil {
+                votes = kept
+                return nil, err
+            }
+            retracted.FavoriteRemoved = true
+        }
+    }
+    if err := appendJSONLines(votesFile, voteRecord{Retracted: retracted.ID}); err != nil {
+        votes = kept
+        if retracted.FavoriteRemoved {
+            undo()
+            if err := saveFavorites(); err != nil {
+                logs.Error("Error restoring favorites: %v", err)
+            }
+        }
+        return nil, err
+    }
+    return retracted, nil
+}
+
+// loved reports whether the ledger holds a love for the image from any
+// user. Favorites are shared, so one stays while anyone still loves it.
+// Callers must hold voteMutex.
+func loved(imageID string) bool {
+    for _, vote := range votes {
+        if vote.ImageID == imageID && vote.Value == VoteLove {
+            return true
+        }
+    }
+    return false
+}
+
+// GetVotes returns the user's votes, oldest first. An empty user ID returns
+// every vote in the ledger.
+func GetVotes(userID string) []Vote {
+    voteMutex.Lock()
+    defer voteMutex.Unlock()
+
+    if err := loadVotes(); err != nil {
+        logs.Error("Error loading votes: %v", err)
+    }
+
+    result := []Vote{}
+    for _, vote := range votes {
+        if userID == "" || vote.UserID == userID {
+            result = append(result, vote)
+        }
+    }
+    return result
+}
+
+// loadVotes reads the persisted ledger on first use. A log with
+// retractions in it is compacted, so it only grows with votes that stand.
+// Callers must hold voteMutex.
+func loadVotes() error {
+    if votesLoaded {
+        return nil
+    }
+
+    loaded := []Vote{}
+    seq, compact := 0, false
+    err := loadJSONLines(votesFile, func(line []byte) error {
+        var record voteRecord
+        if err := json.Unmarshal(line, &record); err != nil {
+            return err
+        }
+        switch {
+        case record.Vote != nil:
+            loaded = append(loaded, *record.Vote)
+            if id, err := strconv.Atoi(record.Vote.ID); err == nil && id > seq {
+                seq = id
+            }
+        case record.Retracted != "":
+            for i, vote := range loaded {
+                if vote.ID == record.Retracted {
+                    loaded = append(loaded[:i:i], loaded[i+1:]...)
+                    break
+                }
+            }
+            compact = true
+        }
+        if record.Seq > seq {
+            seq = record.Seq
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+
+    if compact {
+        records := []interface{}{voteRecord{Seq: seq}}
+        for i := range loaded {
+            records = append(records, voteRecord{Vote: &loaded[i]})
+        }
+        if err := saveJSONLines(votesFile, records); err != nil {
+            return err
+        }
+    }
+
+    votes, voteSeq = loaded, seq
+    votesLoaded = true
+    return nil
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+    "github.com/stretchr/testify/assert"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func resetVotes() {
+    voteMutex.Lock()
+    votes = nil
+    voteSeq = 0
+    votesLoaded = true
+    voteMutex.Unlock()
+}
+
+func TestRecordVote(t *testing.T) {
+    resetVotes()
+    rememberCats([]Cat{{ID: "img1", URL: "http://example.com/1.jpg", Breeds: []Breed{{ID: "abys"}}}})
+
+    vote, err := RecordVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    assert.NoError(t, err)
+    assert.Equal(t, "1", vote.ID)
+    assert.Equal(t, []string{"abys"}, vote.BreedIDs)
+
+    _, err = RecordVote("bob", VoteRequest{ImageID: "img2", Vote: VoteDislike})
+    assert.NoError(t, err)
+
+    assert.Equal(t, 1, len(GetVotes("alice")))
+    assert.Equal(t, 2, len(GetVotes("")))
+}
+
+func TestCastVote(t *testing.T) {
+    resetVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    vote, err := CastVote("alice", VoteRequest{ImageID: "img1", ImageURL: "http://example.com/1.jpg", Vote: VoteLove})
+    assert.NoError(t, err)
+    assert.Equal(t, "1", vote.ID)
+    assert.Equal(t, []string{"img1"}, favoriteIDs(GetFavorites()))
+
+    _, err = CastVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    assert.EqualError(t, err, "already in favorites")
+    assert.Len(t, GetVotes(""), 1, "the vote isn't kept without its favorite")
+}
+
+func TestCastVoteKeepsNoFavoriteWithoutItsVote(t *testing.T) {
+    dir := withDataDir(t)
+    resetVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    // A directory in the way makes writing the ledger fail.
+    assert.NoError(t, os.Mkdir(filepath.Join(dir, votesFile), 0755))
+
+    _, err := CastVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    assert.Error(t, err)
+    assert.Empty(t, GetFavorites())
+
+    favMutex.Lock()
+    favoritesLoaded = false
+    favMutex.Unlock()
+    assert.Empty(t, GetFavorites(), "the saved favorites were restored too")
+}
+
+func TestRecordVoteValidation(t *testing.T) {
+    resetVotes()
+
+    _, err := RecordVote("alice", VoteRequest{ImageID: "img1", Vote: "meh"})
+    assert.EqualError(t, err, `invalid vote: "meh"`)
+
+    _, err = RecordVote("alice", VoteRequest{Vote: VoteLike})
+    assert.Error(t, err)
+    assert.Empty(t, GetVotes(""))
+}
+
+func TestRetractVote(t *testing.T) {
+    resetVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    SaveFavorite("img1", "http://example.com/1.jpg")
+    love, _ := RecordVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    like, _ := RecordVote("bob", VoteRequest{ImageID: "img1", Vote: VoteLike})
+
+    _, err := RetractVote("bob", love.ID)
+    assert.EqualError(t, err, "vote not found", "only the voter can take a vote back")
+
+    retracted, err := RetractVote("alice", love.ID)
+    assert.NoError(t, err)
+    assert.Equal(t, love.ID, retracted.ID)
+    assert.True(t, retracted.FavoriteRemoved)
+    assert.Empty(t, GetFavorites())
+    assert.Equal(t, []Vote{like}, GetVotes(""))
+    trash, _ := GetFavoriteTrash()
+    assert.Len(t, trash, 1, "the favorite can be restored from the trash")
+
+    _, err = RetractVote("alice", love.ID)
+    assert.Error(t, err)
+}
+
+func TestUndoLastVote(t *testing.T) {
+    resetVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    SaveFavorite("img1", "http://example.com/1.jpg")
+    RecordVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    RecordVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLove})
+    RecordVote("bob", VoteRequest{ImageID: "img2", Vote: VoteLike})
+
+    retracted, err := UndoLastVote("alice")
+    assert.NoError(t, err)
+    assert.Equal(t, "2", retracted.ID)
+    assert.False(t, retracted.FavoriteRemoved, "the earlier love still stands")
+    assert.Len(t, GetFavorites(), 1)
+
+    retracted, _ = UndoLastVote("alice")
+    assert.Equal(t, "1", retracted.ID)
+    assert.True(t, retracted.FavoriteRemoved)
+
+    _, err = UndoLastVote("alice")
+    assert.EqualError(t, err, "vote not found")
+    assert.Len(t, GetVotes("bob"), 1)
+}
+
+func TestRetractVoteKeepsFavoritesOthersLove(t *testing.T) {
+    resetVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    CastVote("alice", VoteRequest{ImageID: "img1", ImageURL: "http://example.com/1.jpg", Vote: VoteLove})
+    RecordVote("bob", VoteRequest{ImageID: "img1", Vote: VoteLove})
+
+    retracted, err := UndoLastVote("alice")
+    assert.NoError(t, err)
+    assert.False(t, retracted.FavoriteRemoved, "bob still loves it")
+    assert.Equal(t, []string{"img1"}, favoriteIDs(GetFavorites()))
+
+    retracted, _ = UndoLastVote("bob")
+    assert.True(t, retracted.FavoriteRemoved)
+    assert.Empty(t, GetFavorites())
+}
+
+func TestRetractVoteKeepsTheVoteWhenTheSaveFails(t *testing.T) {
+    dir := withDataDir(t)
+    reloadVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    love, _ := CastVote("alice", VoteRequest{ImageID: "img1", ImageURL: "http://example.com/1.jpg", Vote: VoteLove})
+
+    // A directory in the way makes saving the favorites fail.
+    assert.NoError(t, os.Mkdir(filepath.Join(dir, favoritesFile+".tmp"), 0755))
+
+    _, err := RetractVote("alice", love.ID)
+    assert.Error(t, err)
+    assert.Equal(t, []string{"img1"}, favoriteIDs(GetFavorites()))
+    assert.Equal(t, []Vote{love}, GetVotes(""))
+
+    reloadVotes()
+    assert.Len(t, GetVotes(""), 1, "no retraction was recorded")
+}
+
+func reloadVotes() {
+    voteMutex.Lock()
+    votes, voteSeq, votesLoaded = nil, 0, false
+    voteMutex.Unlock()
+}
+
+func TestVoteLogSurvivesRestart(t *testing.T) {
+    dir := withDataDir(t)
+    reloadVotes()
+    resetFavorites()
+    defer resetFavorites()
+
+    RecordVote("alice", VoteRequest{ImageID: "img1", Vote: VoteLike})
+    RecordVote("alice", VoteRequest{ImageID: "img2", Vote: VoteDislike})
+    UndoLastVote("alice")
+
+    reloadVotes()
+    assert.Len(t, GetVotes(""), 1)
+    vote, _ := RecordVote("bob", VoteRequest{ImageID: "img3", Vote: VoteLike})
+    assert.Equal(t, "3", vote.ID, "retracted IDs aren't handed out again")
+
+    // A record cut short by a crash is skipped.
+    f, _ := os.OpenFile(filepath.Join(dir, votesFile), os.O_WRONLY|os.O_APPEND, 0644)
+    f.Write([]byte(`{"vote":{"id":"4","us`))
+    f.Close()
+    reloadVotes()
+    assert.Len(t, GetVotes(""), 2)
+}
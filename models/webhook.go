@@ -0,0 +1,428 @@
+package models
+
+import (
+    "CatVotingApp/events"
+    "bytes"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/server/web"
+    "io"
+    "net/http"
+    "net/url"
+    "sort"
+    "strconv"
+    "sync"
+    "time"
+)
+
+const (
+    webhooksFile = "webhooks.json"
+
+    // Headers sent with every delivery. The signature is the hex HMAC-SHA256
+    // of "<timestamp>.<body>" keyed with the endpoint's secret.
+    WebhookEventHeader     = "X-Webhook-Event"
+    WebhookDeliveryHeader  = "X-Webhook-Delivery"
+    WebhookTimestampHeader = "X-Webhook-Timestamp"
+    WebhookSignatureHeader = "X-Webhook-Signature"
+
+    webhookTimeout = 10 * time.Second
+)
+
+type WebhookRequest struct {
+    URL    string   `json:"url"`
+    Secret string   `json:"secret"`
+    Events []string `json:"events"`
+}
+
+// Webhook is a registered endpoint. An empty Events list means every event.
+type Webhook struct {
+    ID        string    `json:"id"`
+    URL       string    `json:"url"`
+    Secret    string    `json:"secret,omitempty"`
+    Events    []string  `json:"events,omitempty"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is one event on its way to one endpoint.
+type WebhookDelivery struct {
+    ID          string          `json:"id"`
+    WebhookID   string          `json:"webhook_id"`
+    Event       string          `json:"event"`
+    Payload     json.RawMessage `json:"payload"`
+    Attempts    int             `json:"attempts"`
+    NextAttempt time.Time       `json:"next_attempt"`
+    LastError   string          `json:"last_error,omitempty"`
+    CreatedAt   time.Time       `json:"created_at"`
+}
+
+// webhookState is everything persisted in webhooksFile, saved as one file so
+// the queue never refers to endpoints that weren't written.
+type webhookState struct {
+    Webhooks    []Webhook         `json:"webhooks"`
+    Queue       []WebhookDelivery `json:"queue"`
+    DeadLetters []WebhookDelivery `json:"dead_letters"`
+    Seq         int               `json:"seq"`
+}
+
+// WebhookEvents are the events endpoints can subscribe to.
+var WebhookEvents = []string{
+    events.VoteCastEvent,
+    events.VoteRetractedEvent,
+    events.FavoriteAddedEvent,
+    events.FavoriteRemovedEvent,
+}
+
+// errWebhookDeleted fails deliveries whose endpoint has since been deleted.
+var errWebhookDeleted = fmt.Errorf("webhook no longer exists")
+
+var (
+    webhooks       webhookState
+    webhooksLoaded bool
+    webhookMutex   sync.Mutex
+    webhookClient  = &http.Client{Timeout: webhookTimeout}
+)
+
+// CreateWebhook registers an endpoint. When no secret is given one is
+// generated; it is only ever returned here, so the caller must keep it.
+func CreateWebhook(req WebhookRequest) (*Webhook, error) {
+    target, err := url.Parse(req.URL)
+    if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+        return nil, fmt.Errorf("invalid webhook url: %q", req.URL)
+    }
+    for _, event := range req.Events {
+        if !containsString(WebhookEvents, event) {
+            return nil, fmt.Errorf("unknown webhook event: %q", event)
+        }
+    }
+    if req.Secret == "" {
+        buf := make([]byte, 32)
+        if _, err := rand.Read(buf); err != nil {
+            return nil, err
+        }
+        req.Secret = hex.EncodeToString(buf)
+    }
+
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    if err := loadWebhooks(); err != nil {
+        return nil, err
+    }
+
+    webhooks.Seq++
+    hook := Webhook{
+        ID:        strconv.Itoa(webhooks.Seq),
+        URL:       req.URL,
+        Secret:    req.Secret,
+        Events:    req.Events,
+        CreatedAt: time.Now(),
+    }
+    webhooks.Webhooks = append(webhooks.Webhooks, hook)
+    if err := saveJSON(webhooksFile, webhooks); err != nil {
+        webhooks.Webhooks = webhooks.Webhooks[:len(webhooks.Webhooks)-1]
+        return nil, err
+    }
+    return &hook, nil
+}
+
+// GetWebhooks lists the registered endpoints without their secrets.
+func GetWebhooks() ([]Webhook, error) {
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    if err := loadWebhooks(); err != nil {
+        return nil, err
+    }
+
+    result := make([]Webhook, 0, len(webhooks.Webhooks))
+    for _, hook := range webhooks.Webhooks {
+        hook.Secret = ""
+        result = append(result, hook)
+    }
+    return result, nil
+}
+
+// DeleteWebhook unregisters an endpoint and drops its pending deliveries.
+func DeleteWebhook(id string) error {
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    if err := loadWebhooks(); err != nil {
+        return err
+    }
+
+    for i, hook := range webhooks.Webhooks {
+        if hook.ID != id {
+            continue
+        }
+        webhooks.Webhooks = append(webhooks.Webhooks[:i:i], webhooks.Webhooks[i+1:]...)
+
+        queue := webhooks.Queue[:0:0]
+        for _, delivery := range webhooks.Queue {
+            if delivery.WebhookID != id {
+                queue = append(queue, delivery)
+            }
+        }
+        webhooks.Queue = queue
+        return saveJSON(webhooksFile, webhooks)
+    }
+    return fmt.Errorf("webhook not found: %s", id)
+}
+
+// EnqueueWebhookEvent queues a delivery of the payload to every endpoint
+// subscribed to the event. It returns how many deliveries were queued. The
+// user ID is left out of the payload, as on the live stream: it identifies
+// the user's session.
+func EnqueueWebhookEvent(event string, payload interface{}) (int, error) {
+    body, err := webhookPayload(payload)
+    if err != nil {
+        return 0, err
+    }
+
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    if err := loadWebhooks(); err != nil {
+        return 0, err
+    }
+
+    now := time.Now()
+    queued := 0
+    for _, hook := range webhooks.Webhooks {
+        if !hook.wants(event) {
+            continue
+        }
+        webhooks.Seq++
+        webhooks.Queue = append(webhooks.Queue, WebhookDelivery{
+            ID:          strconv.Itoa(webhooks.Seq),
+            WebhookID:   hook.ID,
+            Event:       event,
+            Payload:     body,
+            NextAttempt: now,
+            CreatedAt:   now,
+        })
+        queued++
+    }
+    if queued == 0 {
+        return 0, nil
+    }
+    return queued, saveJSON(webhooksFile, webhooks)
+}
+
+// DeliverDueWebhooks attempts every queued delivery that is due at now.
+// Failed deliveries are retried with exponential backoff starting at
+// webhook_backoff; after webhook_max_attempts they move to the dead-letter
+// list. It returns how many deliveries succeeded.
+func DeliverDueWebhooks(now time.Time) (int, error) {
+    webhookMutex.Lock()
+    if err := loadWebhooks(); err != nil {
+        webhookMutex.Unlock()
+        return 0, err
+    }
+    hooks := make(map[string]Webhook, len(webhooks.Webhooks))
+    for _, hook := range webhooks.Webhooks {
+        hooks[hook.ID] = hook
+    }
+    due := []WebhookDelivery{}
+    for _, delivery := range webhooks.Queue {
+        if !delivery.NextAttempt.After(now) {
+            due = append(due, delivery)
+        }
+    }
+    webhookMutex.Unlock()
+
+    if len(due) == 0 {
+        return 0, nil
+    }
+
+    // Send without holding the lock: endpoints can be slow.
+    results := make(map[string]error, len(due))
+    for _, delivery := range due {
+        hook, ok := hooks[delivery.WebhookID]
+        if !ok {
+            results[delivery.ID] = errWebhookDeleted
+            continue
+        }
+        results[delivery.ID] = sendWebhook(hook, delivery, now)
+    }
+
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    maxAttempts := web.AppConfig.DefaultInt("webhook_max_attempts", 6)
+    delivered := 0
+    queue := webhooks.Queue[:0:0]
+    for _, delivery := range webhooks.Queue {
+        err, attempted := results[delivery.ID]
+        if !attempted {
+            queue = append(queue, delivery)
+            continue
+        }
+        if err == nil {
+            delivered++
+            continue
+        }
+        if err == errWebhookDeleted {
+            logs.Warn("Dropping webhook delivery %s: %v", delivery.ID, err)
+            continue
+        }
+
+        delivery.Attempts++
+        delivery.LastError = err.Error()
+        if delivery.Attempts >= maxAttempts {
+            webhooks.DeadLetters = append(webhooks.DeadLetters, delivery)
+            continue
+        }
+        delivery.NextAttempt = now.Add(retryBackoff("webhook_backoff", 30*time.Second, delivery.Attempts))
+        queue = append(queue, delivery)
+    }
+    webhooks.Queue = queue
+    return delivered, saveJSON(webhooksFile, webhooks)
+}
+
+// GetWebhookQueue returns the deliveries waiting to be (re)tried.
+func GetWebhookQueue() ([]WebhookDelivery, error) {
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    if err := loadWebhooks(); err != nil {
+        return nil, err
+    }
+    return append([]WebhookDelivery{}, webhooks.Queue...), nil
+}
+
+// GetWebhookDeadLetters returns deliveries that ran out of attempts, newest
+// first.
+func GetWebhookDeadLetters() ([]WebhookDelivery, error) {
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    if err := loadWebhooks(); err != nil {
+        return nil, err
+    }
+
+    result := append([]WebhookDelivery{}, webhooks.DeadLetters...)
+    sort.SliceStable(result, func(i, j int) bool {
+        return result[i].CreatedAt.After(result[j].CreatedAt)
+    })
+    return result, nil
+}
+
+// RedeliverWebhook moves a dead letter back onto the queue with a fresh set
+// of attempts, due immediately.
+func RedeliverWebhook(deliveryID string) (*WebhookDelivery, error) {
+    webhookMutex.Lock()
+    defer webhookMutex.Unlock()
+
+    if err := loadWebhooks(); err != nil {
+        return nil, err
+    }
+
+    for i, delivery := range webhooks.DeadLetters {
+        if delivery.ID != deliveryID {
+            continue
+        }
+        if !webhookExists(delivery.WebhookID) {
+            return nil, fmt.Errorf("%v: %s", errWebhookDeleted, delivery.WebhookID)
+        }
+        webhooks.DeadLetters = append(webhooks.DeadLetters[:i:i], webhooks.DeadLetters[i+1:]...)
+        delivery.Attempts = 0
+        delivery.NextAttempt = time.Now()
+        webhooks.Queue = append(webhooks.Queue, delivery)
+        return &delivery, saveJSON(webhooksFile, webhooks)
+    }
+    return nil, fmt.Errorf("dead letter not found: %s", deliveryID)
+}
+
+// webhookExists reports whether the endpoint is still registered. Callers
+// must hold webhookMutex.
+func webhookExists(id string) bool {
+    for _, hook := range webhooks.Webhooks {
+        if hook.ID == id {
+            return true
+        }
+    }
+    return false
+}
+
+// webhookPayload encodes an event without its user_id field.
+func webhookPayload(payload interface{}) ([]byte, error) {
+    body, err := json.Marshal(payload)
+    if err != nil {
+        return nil, err
+    }
+    fields := map[string]json.RawMessage{}
+    if err := json.Unmarshal(body, &fields); err != nil {
+        return body, nil
+    }
+    if _, ok := fields["user_id"]; !ok {
+        return body, nil
+    }
+    delete(fields, "user_id")
+    return json.Marshal(fields)
+}
+
+// SignWebhook returns the signature a receiver should expect for a body
+// sent at the given Unix timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(timestamp + "."))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func sendWebhook(hook Webhook, delivery WebhookDelivery, now time.Time) error {
+    timestamp := strconv.FormatInt(now.Unix(), 10)
+    req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(delivery.Payload))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(WebhookEventHeader, delivery.Event)
+    req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+    req.Header.Set(WebhookTimestampHeader, timestamp)
+    req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.Secret, timestamp, delivery.Payload))
+
+    resp, err := webhookClient.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, resp.Body)
+
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+    }
+    return nil
+}
+
+func (w Webhook) wants(event string) bool {
+    return len(w.Events) == 0 || containsString(w.Events, event)
+}
+
+// loadWebhooks reads the persisted endpoints and queues on first use.
+// Callers must hold webhookMutex.
+func loadWebhooks() error {
+    if webhooksLoaded {
+        return nil
+    }
+    if err := loadJSON(webhooksFile, &webhooks); err != nil {
+        return err
+    }
+    webhooksLoaded = true
+    return nil
+}
+
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+    "github.com/beego/beego/v2/server/web"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func resetWebhooks() {
+    webhookMutex.Lock()
+    webhooks = webhookState{}
+    webhooksLoaded = true
+    webhookMutex.Unlock()
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+    resetWebhooks()
+    received := make(chan *http.Request, 1)
+    var body []byte
+    receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ = io.ReadAll(r.Body)
+        received <- r
+    }))
+    defer receiver.Close()
+
+    hook, err := CreateWebhook(WebhookRequest{URL: receiver.URL, Secret: "s3cret", Events: []string{"vote_cast"}})
+    assert.NoError(t, err)
+    assert.Equal(t, "s3cret", hook.Secret)
+
+    queued, err := EnqueueWebhookEvent("favorite_removed", map[string]string{"image_id": "abc"})
+    assert.NoError(t, err)
+    assert.Equal(t, 0, queued, "the endpoint did not subscribe to favorite_removed")
+
+    queued, err = EnqueueWebhookEvent("vote_cast", map[string]string{"image_id": "abc", "user_id": "alice"})
+    assert.NoError(t, err)
+    assert.Equal(t, 1, queued)
+
+    delivered, err := DeliverDueWebhooks(time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, 1, delivered)
+
+    r := <-received
+    assert.Equal(t, "vote_cast", r.Header.Get(WebhookEventHeader))
+    assert.JSONEq(t, `{"image_id":"abc"}`, string(body), "the user ID is left out")
+    assert.Equal(t, SignWebhook("s3cret", r.Header.Get(WebhookTimestampHeader), body), r.Header.Get(WebhookSignatureHeader))
+
+    queue, _ := GetWebhookQueue()
+    assert.Empty(t, queue)
+
+    listed, _ := GetWebhooks()
+    assert.Equal(t, "", listed[0].Secret, "secrets are not listed")
+}
+
+func TestWebhookRetriesThenDeadLetters(t *testing.T) {
+    resetWebhooks()
+    web.AppConfig.Set("webhook_max_attempts", "3")
+    web.AppConfig.Set("webhook_backoff", "1m")
+    defer web.AppConfig.Set("webhook_max_attempts", "6")
+    defer web.AppConfig.Set("webhook_backoff", "30s")
+
+    healthy := false
+    receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if !healthy {
+            w.WriteHeader(http.StatusInternalServerError)
+        }
+    }))
+    defer receiver.Close()
+
+    _, err := CreateWebhook(WebhookRequest{URL: receiver.URL})
+    assert.NoError(t, err)
+    EnqueueWebhookEvent("vote_cast", map[string]string{"image_id": "abc"})
+
+    now := time.Now()
+    delivered, err := DeliverDueWebhooks(now)
+    assert.NoError(t, err)
+    assert.Equal(t, 0, delivered)
+
+    queue, _ := GetWebhookQueue()
+    assert.Equal(t, 1, queue[0].Attempts)
+    assert.Equal(t, now.Add(time.Minute), queue[0].NextAttempt)
+    assert.Contains(t, queue[0].LastError, "status 500")
+
+    // Not due yet.
+    DeliverDueWebhooks(now.Add(30 * time.Second))
+    queue, _ = GetWebhookQueue()
+    assert.Equal(t, 1, queue[0].Attempts)
+
+    // The backoff doubles: one minute, then two.
+    DeliverDueWebhooks(now.Add(time.Minute))
+    queue, _ = GetWebhookQueue()
+    assert.Equal(t, now.Add(3*time.Minute), queue[0].NextAttempt)
+
+    DeliverDueWebhooks(now.Add(3 * time.Minute))
+    queue, _ = GetWebhookQueue()
+    assert.Empty(t, queue)
+
+    deadLetters, _ := GetWebhookDeadLetters()
+    assert.Equal(t, 1, len(deadLetters))
+    assert.Equal(t, 3, deadLetters[0].Attempts)
+
+    healthy = true
+    _, err = RedeliverWebhook(deadLetters[0].ID)
+    assert.NoError(t, err)
+    delivered, _ = DeliverDueWebhooks(time.Now())
+    assert.Equal(t, 1, delivered)
+
+    deadLetters, _ = GetWebhookDeadLetters()
+    assert.Empty(t, deadLetters)
+    _, err = RedeliverWebhook("missing")
+    assert.Error(t, err)
+}
+
+func TestWebhookDeliveriesOfDeletedEndpointsAreDropped(t *testing.T) {
+    resetWebhooks()
+    hook, _ := CreateWebhook(WebhookRequest{URL: "http://127.0.0.1:1"})
+    EnqueueWebhookEvent("vote_cast", map[string]string{"image_id": "abc"})
+
+    // A dead letter redelivered after its endpoint went away.
+    webhookMutex.Lock()
+    webhooks.DeadLetters, webhooks.Queue = webhooks.Queue, nil
+    webhookMutex.Unlock()
+    assert.NoError(t, DeleteWebhook(hook.ID))
+    letters, _ := GetWebhookDeadLetters()
+    _, err := RedeliverWebhook(letters[0].ID)
+    assert.Error(t, err)
+
+    webhookMutex.Lock()
+    webhooks.Queue, webhooks.DeadLetters = webhooks.DeadLetters, nil
+    webhookMutex.Unlock()
+    delivered, err := DeliverDueWebhooks(time.Now())
+    assert.NoError(t, err)
+    assert.Equal(t, 0, delivered)
+    queue, _ := GetWebhookQueue()
+    assert.Empty(t, queue, "the delivery isn't picked up again on every tick")
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+    resetWebhooks()
+
+    _, err := CreateWebhook(WebhookRequest{URL: "ftp://example.com"})
+    assert.Error(t, err)
+    _, err = CreateWebhook(WebhookRequest{URL: "http://example.com", Events: []string{"nope"}})
+    assert.Error(t, err)
+
+    hook, err := CreateWebhook(WebhookRequest{URL: "http://example.com"})
+    assert.NoError(t, err)
+    assert.Equal(t, 64, len(hook.Secret), "a secret is generated when none is given")
+
+    assert.NoError(t, DeleteWebhook(hook.ID))
+    assert.Error(t, DeleteWebhook(hook.ID))
+}
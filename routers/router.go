@@ -7,14 +7,55 @@ import (
 
 func init() {
     web.Router("/", &controllers.CatController{})
+    web.Router("/compare", &controllers.CatController{}, "get:ComparePage")
+    web.Router("/share/:token", &controllers.CatController{}, "get:SharePage")
     
     ns := web.NewNamespace("/api",
         web.NSRouter("/cats", &controllers.CatController{}, "get:GetCats"),
         web.NSRouter("/breeds", &controllers.CatController{}, "get:GetBreeds"),
+        web.NSRouter("/breeds/suggest", &controllers.CatController{}, "get:SuggestBreeds"),
+        web.NSRouter("/breeds/compare", &controllers.CatController{}, "get:CompareBreeds"),
+        web.NSRouter("/breeds/match", &controllers.CatController{}, "post:MatchBreeds"),
+        web.NSRouter("/breeds/:id/similar", &controllers.CatController{}, "get:GetSimilarBreeds"),
         web.NSRouter("/breed", &controllers.CatController{}, "get:GetBreedDetails"),
+        web.NSRouter("/search", &controllers.CatController{}, "get:SearchBreeds"),
         web.NSRouter("/vote", &controllers.CatController{}, "post:VoteCat"),
+        web.NSRouter("/votes/batch", &controllers.CatController{}, "post:BatchVotes"),
+        web.NSRouter("/votes/undo", &controllers.CatController{}, "post:UndoVote"),
+        web.NSRouter("/votes/:id", &controllers.CatController{}, "delete:RetractVote"),
+        web.NSRouter("/stream", &controllers.CatController{}, "get:Stream"),
+        web.NSRouter("/trending", &controllers.CatController{}, "get:GetTrending"),
+        web.NSRouter("/cat-of-the-day", &controllers.CatController{}, "get:GetCatOfTheDay"),
+        web.NSRouter("/cat-of-the-day/history", &controllers.CatController{}, "get:GetCatOfTheDayHistory"),
+        web.NSRouter("/duel", &controllers.CatController{}, "get:GetDuel;post:RecordDuel"),
+        web.NSRouter("/duel/rankings", &controllers.CatController{}, "get:GetDuelRankings"),
+        web.NSRouter("/polls", &controllers.CatController{}, "get:GetPolls;post:CreatePoll"),
+        web.NSRouter("/polls/:id", &controllers.CatController{}, "get:GetPoll"),
+        web.NSRouter("/polls/:id/vote", &controllers.CatController{}, "post:VotePoll"),
+        web.NSRouter("/polls/:id/results", &controllers.CatController{}, "get:GetPollResults"),
         web.NSRouter("/favorites", &controllers.CatController{}, "get:GetFavorites"),
-        web.NSRouter("/favorites/:id", &controllers.CatController{}, "delete:RemoveFavorite")    )
+        web.NSRouter("/favorites/tags", &controllers.CatController{}, "get:GetFavoriteTags"),
+        web.NSRouter("/favorites/breeds", &controllers.CatController{}, "get:GetFavoriteBreeds"),
+        web.NSRouter("/favorites/order", &controllers.CatController{}, "put:ReorderFavorites"),
+        web.NSRouter("/favorites/trash", &controllers.CatController{}, "get:GetFavoriteTrash"),
+        web.NSRouter("/favorites/export", &controllers.CatController{}, "get:ExportFavorites"),
+        web.NSRouter("/favorites/export/:job", &controllers.CatController{}, "get:GetExportJob"),
+        web.NSRouter("/favorites/export/:job/download", &controllers.CatController{}, "get:DownloadExport"),
+        web.NSRouter("/favorites/import", &controllers.CatController{}, "post:ImportFavorites"),
+        web.NSRouter("/favorites/batch", &controllers.CatController{}, "post:BatchFavorites"),
+        web.NSRouter("/shares", &controllers.CatController{}, "get:GetShares;post:CreateShare"),
+        web.NSRouter("/shares/:token", &controllers.CatController{}, "delete:RevokeShare"),
+        web.NSRouter("/favorites/:id/restore", &controllers.CatController{}, "post:RestoreFavorite"),
+        web.NSRouter("/favorites/:id", &controllers.CatController{}, "delete:RemoveFavorite;patch:UpdateFavorite"),
+        web.NSRouter("/collections", &controllers.CatController{}, "get:GetCollections;post:CreateCollection"),
+        web.NSRouter("/collections/:id", &controllers.CatController{}, "put:RenameCollection;delete:DeleteCollection"),
+        web.NSRouter("/collections/:id/images", &controllers.CatController{}, "post:AddToCollection"),
+        web.NSRouter("/collections/:id/images/:image_id", &controllers.CatController{}, "delete:RemoveFromCollection"),
+        web.NSRouter("/integrations/slash", &controllers.CatController{}, "post:SlashCommand"),
+        web.NSRouter("/admin/webhooks", &controllers.CatController{}, "get:GetWebhooks;post:CreateWebhook"),
+        web.NSRouter("/admin/webhooks/dead-letters", &controllers.CatController{}, "get:GetWebhookDeadLetters"),
+        web.NSRouter("/admin/webhooks/dead-letters/:id/redeliver", &controllers.CatController{}, "post:RedeliverWebhook"),
+        web.NSRouter("/admin/webhooks/:id", &controllers.CatController{}, "delete:DeleteWebhook")    )
     
     web.AddNamespace(ns)
     web.SetStaticPath("/static", "static")
@@ -109,33 +109,89 @@ func TestNamespaceConfiguration(t *testing.T) {
     apiRoutes := []string{
         "/api/cats",
         "/api/breeds",
+        "/api/breeds/suggest",
+        "/api/breeds/compare",
+        "/api/breeds/abys/similar",
         "/api/breed",
+        "/api/search",
         "/api/vote",
+        "/api/stream",
+        "/api/trending",
+        "/api/cat-of-the-day",
+        "/api/cat-of-the-day/history",
+        "/api/duel",
+        "/api/duel/rankings",
+        "/api/polls",
+        "/api/polls/1",
+        "/api/polls/1/results",
         "/api/favorites",
         "/api/favorites/123",
+        "/api/favorites/tags",
+        "/api/favorites/breeds",
+        "/api/favorites/trash",
+        "/api/favorites/export",
+        "/api/favorites/export/abc",
+        "/api/favorites/export/abc/download",
+        "/api/shares",
+        "/api/collections",
+        "/api/admin/webhooks",
+        "/api/admin/webhooks/dead-letters",
     }
 
     for _, route := range apiRoutes {
         t.Run("Route "+route, func(t *testing.T) {
-            // Create test request for the route
-            r, err := http.NewRequest("GET", route, nil)
-            assert.NoError(t, err)
-
-            // Create response recorder to capture the response
-            w := httptest.NewRecorder()
+            assertRouteRegistered(t, "GET", route)
+        })
+    }
 
-            // Create a new context using the correct package
-            ctx := context.NewContext()
-            ctx.Reset(w, r)
+    // Routes that only accept writes are probed with their own method.
+    apiWriteRoutes := []struct {
+        method string
+        path   string
+    }{
+        {"POST", "/api/breeds/match"},
+        {"POST", "/api/polls/1/vote"},
+        {"DELETE", "/api/admin/webhooks/1"},
+        {"POST", "/api/admin/webhooks/dead-letters/1/redeliver"},
+        {"POST", "/api/integrations/slash"},
+        {"PUT", "/api/collections/1"},
+        {"POST", "/api/collections/1/images"},
+        {"DELETE", "/api/collections/1/images/123"},
+        {"PUT", "/api/favorites/order"},
+        {"POST", "/api/favorites/123/restore"},
+        {"POST", "/api/favorites/import"},
+        {"DELETE", "/api/shares/abc"},
+        {"POST", "/api/favorites/batch"},
+        {"POST", "/api/votes/batch"},
+        {"POST", "/api/votes/undo"},
+        {"DELETE", "/api/votes/1"},
+    }
 
-            // Verify the route exists by attempting to match it
-            routeInfo, found := web.BeeApp.Handlers.FindRouter(ctx)
-            assert.True(t, found, "Route should be registered: "+route)
-            assert.NotNil(t, routeInfo, "Route info should not be nil: "+route)
+    for _, route := range apiWriteRoutes {
+        t.Run("Route "+route.method+" "+route.path, func(t *testing.T) {
+            assertRouteRegistered(t, route.method, route.path)
         })
     }
 }
 
+func assertRouteRegistered(t *testing.T, method string, route string) {
+    // Create test request for the route
+    r, err := http.NewRequest(method, route, nil)
+    assert.NoError(t, err)
+
+    // Create response recorder to capture the response
+    w := httptest.NewRecorder()
+
+    // Create a new context using the correct package
+    ctx := context.NewContext()
+    ctx.Reset(w, r)
+
+    // Verify the route exists by attempting to match it
+    routeInfo, found := web.BeeApp.Handlers.FindRouter(ctx)
+    assert.True(t, found, "Route should be registered: "+route)
+    assert.NotNil(t, routeInfo, "Route info should not be nil: "+route)
+}
+
 
 func TestStaticPathConfiguration(t *testing.T) {
     initTestRoutes()
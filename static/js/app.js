@@ -43,7 +43,8 @@ document.addEventListener('DOMContentLoaded', () => {
     // Voting section
     async function loadCats() {
         try {
-            const response = await fetch('/api/cats');
+            const mode = document.getElementById('stream-mode').value;
+            const response = await fetch(`/api/cats?mode=${mode}`);
             const data = await response.json();
             if (data.status === 'success') {
                 currentCats = data.data;
@@ -81,9 +82,18 @@ document.addEventListener('DOMContentLoaded', () => {
                 return;
             }
 
+            // The same key for repeated clicks on the same cat makes the
+            // server replay the first answer instead of voting twice.
+            if (!cat.voteKey) {
+                cat.voteKey = `${cat.id}-${Date.now()}-${Math.random().toString(36).slice(2)}`;
+            }
+
             const response = await fetch('/api/vote', {
                 method: 'POST',
-                headers: { 'Content-Type': 'application/json' },
+                headers: {
+                    'Content-Type': 'application/json',
+                    'Idempotency-Key': `${cat.voteKey}-${vote}`
+                },
                 body: JSON.stringify({
                     image_id: cat.id,
                     image_url: cat.url,
@@ -96,6 +106,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 throw new Error(data.message);
             }
 
+            document.querySelector('.undo-vote').hidden = false;
             currentCatIndex++;
             if (currentCatIndex >= currentCats.length) {
                 await loadCats();
@@ -107,6 +118,29 @@ document.addEventListener('DOMContentLoaded', () => {
         }
     }
 
+    // Takes back the last vote and shows its cat again, when it's still in
+    // the current batch.
+    async function undoVote() {
+        const button = document.querySelector('.undo-vote');
+        try {
+            const response = await fetch('/api/votes/undo', { method: 'POST' });
+            const data = await response.json();
+            if (data.status !== 'success') {
+                throw new Error(data.message);
+            }
+
+            button.hidden = true;
+            const previous = currentCats[currentCatIndex - 1];
+            if (previous && previous.id === data.data.image_id) {
+                delete previous.voteKey;
+                currentCatIndex--;
+                displayCurrentCat();
+            }
+        } catch (error) {
+            console.error('Error undoing vote:', error);
+        }
+    }
+
     async function loadBreedsWithDefault() {
         try {
             const response = await fetch('/api/breeds');
@@ -213,6 +247,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 updateBreedDisplay(breed); // Pass the breed object
                 loadingEl.classList.add('hidden');
                 breedDetails.style.display = 'block';
+                loadSimilarBreeds(breedId);
             }
         } catch (error) {
             console.error('Error loading breed details:', error);
@@ -222,6 +257,44 @@ document.addEventListener('DOMContentLoaded', () => {
 
 
 
+    async function loadSimilarBreeds(breedId) {
+        const container = document.querySelector('.similar-breeds');
+        const list = container.querySelector('.similar-list');
+        list.innerHTML = '';
+        container.classList.add('hidden');
+
+        try {
+            const response = await fetch(`/api/breeds/${encodeURIComponent(breedId)}/similar?n=5`);
+            const data = await response.json();
+            if (data.status !== 'success' || data.data.length === 0) {
+                return;
+            }
+
+            data.data.forEach(similar => {
+                const item = document.createElement('li');
+                const link = document.createElement('a');
+                link.href = '#';
+                link.textContent = `${similar.name} (${Math.round(similar.similarity * 100)}%)`;
+                link.addEventListener('click', (e) => {
+                    e.preventDefault();
+                    document.getElementById('breed-select').value = similar.id;
+                    loadBreedDetails(similar.id);
+                });
+
+                const traits = document.createElement('span');
+                traits.className = 'similar-traits';
+                traits.textContent = similar.shared_traits.map(t => t.label).join(', ');
+
+                item.appendChild(link);
+                item.appendChild(traits);
+                list.appendChild(item);
+            });
+            container.classList.remove('hidden');
+        } catch (error) {
+            console.error('Error loading similar breeds:', error);
+        }
+    }
+
     function updateBreedDisplay(breed) {
         const breedTitle = document.querySelector('.breed-title');
         const breedOrigin = document.querySelector('.breed-origin');
@@ -447,6 +520,15 @@ document.addEventListener('DOMContentLoaded', () => {
 
                     itemDiv.appendChild(img);
                     itemDiv.appendChild(removeBtn);
+
+                    const breeds = (favorite.image && favorite.image.breeds) || [];
+                    if (breeds.length > 0) {
+                        const caption = document.createElement('span');
+                        caption.className = 'favorite-breed';
+                        caption.textContent = breeds.map(breed => breed.name).join(', ');
+                        img.alt = caption.textContent;
+                        itemDiv.appendChild(caption);
+                    }
                     grid.appendChild(itemDiv);
 
                     img.onerror = () => {
@@ -464,7 +546,54 @@ document.addEventListener('DOMContentLoaded', () => {
     document.querySelector('.vote-btn.like').addEventListener('click', () => voteCat('like'));
     document.querySelector('.vote-btn.dislike').addEventListener('click', () => voteCat('dislike'));
     document.querySelector('.vote-btn.favorite').addEventListener('click', () => voteCat('love'));
+    document.querySelector('.undo-vote').addEventListener('click', undoVote);
+
+    document.getElementById('stream-mode').addEventListener('change', () => loadCats());
+
+    // Leaderboard
+    function renderLeaderboard(trending) {
+        const list = document.querySelector('.leaderboard-list');
+        list.innerHTML = '';
+        (trending.images || []).forEach(item => {
+            const img = document.createElement('img');
+            img.src = item.url;
+            img.alt = 'Trending cat';
+            img.title = `Score ${item.score}`;
+            list.appendChild(img);
+        });
+    }
+
+    async function loadLeaderboard() {
+        try {
+            const response = await fetch('/api/trending?limit=5');
+            const data = await response.json();
+            if (data.status === 'success') {
+                renderLeaderboard(data.data);
+            }
+        } catch (error) {
+            console.error('Error loading leaderboard:', error);
+        }
+    }
+
+    // Live updates
+    function connectStream() {
+        const source = new EventSource('/api/stream');
+        const refreshFavorites = () => {
+            if (document.getElementById('favs').classList.contains('active')) {
+                loadFavorites();
+            }
+        };
+
+        source.addEventListener('favorite_added', refreshFavorites);
+        source.addEventListener('favorite_removed', refreshFavorites);
+        source.addEventListener('leaderboard_changed', (e) => {
+            renderLeaderboard(JSON.parse(e.data).data);
+        });
+        source.onerror = () => console.error('Live update stream interrupted, retrying');
+    }
 
     // Initial load
     loadCats();
+    loadLeaderboard();
+    connectStream();
 });
\ No newline at end of file
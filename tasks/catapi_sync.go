@@ -0,0 +1,59 @@
+package tasks
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "context"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/task"
+    "time"
+)
+
+// Mirroring to TheCatAPI is optional (catapi_sync in conf/app.conf). When
+// it is on, local votes and favorite changes go into a durable outbox that
+// is flushed every thirty seconds, and the favourites already stored
+// upstream are imported on startup.
+func init() {
+    if !models.CatAPISyncEnabled() {
+        return
+    }
+
+    events.SubscribeAsync(events.VoteCastEvent, "catapi_sync", func(e events.Event) error {
+        cast := e.(events.VoteCast)
+        return models.QueueVoteSync(cast.ImageID, cast.Value)
+    })
+    events.SubscribeAsync(events.VoteRetractedEvent, "catapi_sync", func(e events.Event) error {
+        retracted := e.(events.VoteRetracted)
+        return models.QueueUnvoteSync(retracted.ImageID, retracted.Value)
+    })
+    events.SubscribeAsync(events.FavoriteAddedEvent, "catapi_sync", func(e events.Event) error {
+        return models.QueueFavouriteSync(e.(events.FavoriteAdded).ImageID)
+    })
+    events.SubscribeAsync(events.FavoriteRemovedEvent, "catapi_sync", func(e events.Event) error {
+        return models.QueueUnfavouriteSync(e.(events.FavoriteRemoved).ImageID)
+    })
+
+    task.AddTask("catapi_sync", task.NewTask("catapi_sync", "*/30 * * * * *", flushCatAPISync))
+    go importCatAPIFavourites()
+}
+
+func flushCatAPISync(ctx context.Context) error {
+    synced, err := models.FlushCatAPISync(time.Now())
+    if err != nil {
+        logs.Error("Error syncing with TheCatAPI: %v", err)
+        return err
+    }
+    if synced > 0 {
+        logs.Info("Synced %d changes to TheCatAPI", synced)
+    }
+    return nil
+}
+
+func importCatAPIFavourites() {
+    added, err := models.ImportCatAPIFavourites()
+    if err != nil {
+        logs.Error("Error importing favourites from TheCatAPI: %v", err)
+        return
+    }
+    logs.Info("Imported %d favourites from TheCatAPI", added)
+}
@@ -0,0 +1,85 @@
+package tasks
+
+import (
+    "CatVotingApp/events"
+    "CatVotingApp/models"
+    "context"
+    "github.com/beego/beego/v2/core/logs"
+    "github.com/beego/beego/v2/task"
+    "time"
+)
+
+func init() {
+    // A minute past midnight, once yesterday's votes are all in.
+    task.AddTask("cat_of_the_day", task.NewTask("cat_of_the_day", "0 1 0 * * *", pickCatOfTheDay))
+    task.AddTask("favorite_images", task.NewTask("favorite_images", "0 */10 * * * *", backfillFavoriteImages))
+    task.AddTask("favorites_trash", task.NewTask("favorites_trash", "0 30 * * * *", purgeFavoriteTrash))
+    task.AddTask("favorite_exports", task.NewTask("favorite_exports", "0 45 * * * *", purgeFavoriteExports))
+    task.AddTask("share_views", task.NewTask("share_views", "30 * * * * *", flushShareViews))
+}
+
+func pickCatOfTheDay(ctx context.Context) error {
+    pick, err := models.GetCatOfTheDay()
+    if err != nil {
+        logs.Error("Error picking cat of the day: %v", err)
+        return err
+    }
+    logs.Info("Cat of the day for %s: %s (%s)", pick.Date, pick.Cat.ID, pick.Reason)
+    events.Publish(events.CatOfTheDayChosen{
+        Date:     pick.Date,
+        ImageID:  pick.Cat.ID,
+        ImageURL: pick.Cat.URL,
+        Reason:   pick.Reason,
+        ChosenAt: pick.ChosenAt,
+    })
+    return nil
+}
+
+// backfillFavoriteImages records the breeds and dimensions of favorites
+// saved before the image was known, a batch at a time.
+func backfillFavoriteImages(ctx context.Context) error {
+    backfilled, err := models.BackfillFavoriteImages(50)
+    if err != nil {
+        logs.Error("Error backfilling favorite images: %v", err)
+        return err
+    }
+    if backfilled > 0 {
+        logs.Info("Backfilled %d favorite images", backfilled)
+    }
+    return nil
+}
+
+// purgeFavoriteTrash deletes removed favorites once their retention is up.
+func purgeFavoriteTrash(ctx context.Context) error {
+    purged, err := models.PurgeFavoriteTrash(time.Now())
+    if err != nil {
+        logs.Error("Error purging favorites trash: %v", err)
+        return err
+    }
+    if purged > 0 {
+        logs.Info("Purged %d favorites from the trash", purged)
+    }
+    return nil
+}
+
+// purgeFavoriteExports deletes ZIP exports nobody downloaded in time.
+func purgeFavoriteExports(ctx context.Context) error {
+    purged, err := models.PurgeExportJobs(time.Now())
+    if err != nil {
+        logs.Error("Error purging favorite exports: %v", err)
+        return err
+    }
+    if purged > 0 {
+        logs.Info("Purged %d favorite exports", purged)
+    }
+    return nil
+}
+
+// flushShareViews saves share view counts that are waiting in memory.
+func flushShareViews(ctx context.Context) error {
+    if err := models.FlushShareViews(); err != nil {
+        logs.Error("Error saving share views: %v", err)
+        return err
+    }
+    return nil
+}
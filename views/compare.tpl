@@ -0,0 +1,75 @@
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Compare Breeds - Cat Voting App</title>
+    <link rel="stylesheet" href="/static/css/style.css">
+</head>
+<body>
+    <div class="app-container">
+        <main class="content">
+            <section class="compare-container">
+                <h2>Breed Comparison</h2>
+
+                {{if .Error}}
+                <p class="compare-error">{{.Error}}</p>
+                {{if .Errors}}
+                <ul class="compare-errors">
+                    {{range $id, $message := .Errors}}
+                    <li><strong>{{$id}}</strong>: {{$message}}</li>
+                    {{end}}
+                </ul>
+                {{end}}
+                {{end}}
+
+                {{with .Comparison}}
+                <table class="compare-table">
+                    <thead>
+                        <tr>
+                            <th></th>
+                            {{range .Breeds}}
+                            <th>
+                                {{if .ImageURL}}<img src="{{.ImageURL}}" alt="{{.Name}}">{{end}}
+                                <span>{{.Name}}</span>
+                            </th>
+                            {{end}}
+                        </tr>
+                    </thead>
+                    <tbody>
+                        <tr>
+                            <th>Origin</th>
+                            {{range .Breeds}}<td>{{.Origin}}</td>{{end}}
+                        </tr>
+                        <tr>
+                            <th>Life span (years)</th>
+                            {{range .Breeds}}<td>{{.LifeSpan.Min}} – {{.LifeSpan.Max}}</td>{{end}}
+                        </tr>
+                        <tr>
+                            <th>Weight (kg)</th>
+                            {{range .Breeds}}<td>{{.Weight.Min}} – {{.Weight.Max}}</td>{{end}}
+                        </tr>
+                        {{range $trait := .Traits}}
+                        <tr>
+                            <th>{{$trait.Label}}</th>
+                            {{range $.Comparison.Breeds}}
+                            <td><span class="trait-score score-{{index .Traits $trait.Key}}">{{index .Traits $trait.Key}}</span> / 5</td>
+                            {{end}}
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+
+                {{if .Errors}}
+                <ul class="compare-errors">
+                    {{range $id, $message := .Errors}}
+                    <li><strong>{{$id}}</strong>: {{$message}}</li>
+                    {{end}}
+                </ul>
+                {{end}}
+                {{end}}
+
+                <a href="/" class="back-link">← Back to voting</a>
+            </section>
+        </main>
+    </div>
+</body>
+</html>
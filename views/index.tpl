@@ -32,6 +32,12 @@
             <!-- Voting section remains unchanged -->
             <section id="voting" class="tab-content active">
                 <div class="voting-container">
+                    <div class="stream-mode-wrapper">
+                        <select id="stream-mode">
+                            <option value="random">Random cats</option>
+                            <option value="personal">Picked for you</option>
+                        </select>
+                    </div>
                     <div class="cat-image">
                         <img src="" alt="Cat">
                     </div>
@@ -40,6 +46,11 @@
                         <button class="vote-btn favorite">❤️</button>
                         <button class="vote-btn like">👍</button>
                     </div>
+                    <button class="undo-vote" hidden>↩ Undo last vote</button>
+                    <div class="leaderboard">
+                        <h3>Trending now</h3>
+                        <div class="leaderboard-list"></div>
+                    </div>
                 </div>
             </section>
 
@@ -68,6 +79,10 @@
                             <span class="breed-origin"></span>
                             <p class="breed-description"></p>
                             <a href="" target="_blank" class="wiki-link">WIKIPEDIA</a>
+                            <div class="similar-breeds hidden">
+                                <h3>Similar breeds</h3>
+                                <ul class="similar-list"></ul>
+                            </div>
                         </div>
                     </div>
                 </div>
@@ -0,0 +1,56 @@
+<!DOCTYPE html>
+<html>
+<head>
+    {{with .Shared}}
+    <title>{{.Share.Title}} - Cat Voting App</title>
+    <meta property="og:type" content="website">
+    <meta property="og:site_name" content="Cat Voting App">
+    <meta property="og:title" content="{{.Share.Title}}">
+    <meta property="og:description" content="{{len .Images}} favorite cats on Cat Voting App">
+    <meta property="og:url" content="{{$.PageURL}}">
+    {{if $.PreviewImage}}
+    <meta property="og:image" content="{{$.PreviewImage}}">
+    <meta name="twitter:card" content="summary_large_image">
+    {{end}}
+    {{else}}
+    <title>Share not found - Cat Voting App</title>
+    <meta name="robots" content="noindex">
+    {{end}}
+    <link rel="stylesheet" href="/static/css/style.css">
+</head>
+<body>
+    <div class="app-container">
+        <main class="content">
+            <section class="share-container">
+                {{if .Error}}
+                <h2>Nothing to see here</h2>
+                <p class="share-error">{{.Error}}</p>
+                {{end}}
+
+                {{with .Shared}}
+                <h2>{{.Share.Title}}</h2>
+                {{if .Images}}
+                <div class="share-grid">
+                    {{range .Images}}
+                    <figure class="share-item">
+                        <img src="{{.URL}}" alt="{{with .Image}}{{range $i, $b := .Breeds}}{{if not $i}}{{$b.Name}}{{end}}{{end}}{{else}}A cat{{end}}" loading="lazy">
+                        {{if or .Image .Tags}}
+                        <figcaption>
+                            {{with .Image}}{{range $i, $b := .Breeds}}{{if not $i}}<span class="share-breed">{{$b.Name}}</span>{{end}}{{end}}{{end}}
+                            {{range .Tags}}<span class="share-tag">#{{.}}</span>{{end}}
+                        </figcaption>
+                        {{end}}
+                    </figure>
+                    {{end}}
+                </div>
+                {{else}}
+                <p>No cats here yet.</p>
+                {{end}}
+                {{end}}
+
+                <a href="/" class="back-link">← Vote on more cats</a>
+            </section>
+        </main>
+    </div>
+</body>
+</html>